@@ -0,0 +1,551 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/syncer"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap/zaptest"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/health"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/indexer"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/logging"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/services"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/subscription"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+const (
+	alice = "TAmVDhmNuC4Qsb3xkwYmzGpBkbtaQUGusx"
+	bob   = "TBjJvmhWAT1uLDuA5FU6DvGWpBjt8TBXsu"
+	carol = "TCpHbRrEyUVp7UdTbSYxRQbGSSLdAdCkyM"
+)
+
+// dataChecker performs the equivalent of rosetta-cli check:data, it syncs
+// every block served by the gateway, computes the balance of every account
+// from the operations and reconciles them against /account/balance
+type dataChecker struct {
+	t          *testing.T
+	url        string
+	network    *types.NetworkIdentifier
+	fetcher    *fetcher.Fetcher
+	parser     *parser.Parser
+	syncer     *syncer.Syncer
+	reconciler *reconciler.Reconciler
+
+	head     *types.BlockIdentifier
+	blocks   map[string]*types.Block
+	accounts map[string]*reconciler.AccountCurrency
+	balances map[string]string
+	removed  int
+}
+
+func newDataChecker(ctx context.Context, t *testing.T, gatewayURL string) *dataChecker {
+	f := fetcher.New(gatewayURL, fetcher.WithMaxRetries(1), fetcher.WithRetryElapsedTime(5*time.Second))
+	network, _, err := f.InitializeAsserter(ctx)
+	if err != nil {
+		t.Fatalf("unable to initialize asserter: %v", err)
+	}
+
+	checker := &dataChecker{
+		t:        t,
+		url:      gatewayURL,
+		network:  network,
+		fetcher:  f,
+		parser:   parser.New(f.Asserter, nil),
+		blocks:   make(map[string]*types.Block),
+		accounts: make(map[string]*reconciler.AccountCurrency),
+		balances: make(map[string]string),
+	}
+	checker.syncer = syncer.New(network, f, checker, func() {}, nil)
+	checker.reconciler = reconciler.New(network, checker, nil, f, reconciler.WithLookupBalanceByBlock(false))
+
+	return checker
+}
+
+func balanceKey(account *types.AccountIdentifier, currency *types.Currency) string {
+	return types.Hash(account) + "/" + types.Hash(currency)
+}
+
+func (checker *dataChecker) applyChanges(ctx context.Context, block *types.Block, removed bool) error {
+	changes, err := checker.parser.BalanceChanges(ctx, block, removed)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		key := balanceKey(change.Account, change.Currency)
+		balance, ok := checker.balances[key]
+		if !ok {
+			balance = "0"
+		}
+		if checker.balances[key], err = types.AddValues(balance, change.Difference); err != nil {
+			return err
+		}
+		checker.accounts[key] = &reconciler.AccountCurrency{
+			Account:  change.Account,
+			Currency: change.Currency,
+		}
+	}
+
+	return nil
+}
+
+// checkOperations ensures transactions never create XZC, only coinbases may,
+// and that the fees credited to the fee pool are claimed by the coinbase
+func (checker *dataChecker) checkOperations(block *types.Block) {
+	var fees, claimed int64
+	for i, tx := range block.Transactions {
+		sum := new(big.Int)
+		var fee int64
+		for _, op := range tx.Operations {
+			if op.Amount.Currency.Symbol != mapper.Currency.Symbol {
+				continue
+			}
+			value, _ := new(big.Int).SetString(op.Amount.Value, 10)
+			sum.Add(sum, value)
+			if op.Type == client.ActionTypeFee {
+				fee += value.Int64()
+				continue
+			}
+			if op.OperationIdentifier.NetworkIndex == nil {
+				checker.t.Errorf("operation %d of %s has no network index", op.OperationIdentifier.Index, tx.TransactionIdentifier.Hash)
+			}
+			if i > 0 && op.Amount.Value[0] != '-' && len(op.RelatedOperations) == 0 {
+				checker.t.Errorf("output operation %d of %s is not related to its inputs", op.OperationIdentifier.Index, tx.TransactionIdentifier.Hash)
+			}
+		}
+		if i == 0 {
+			claimed = -fee
+			continue
+		}
+		fees += fee
+		if sum.Sign() != 0 {
+			checker.t.Errorf("block %d transaction %s leaves %s unaccounted", block.BlockIdentifier.Index, tx.TransactionIdentifier.Hash, sum)
+		}
+		if metadataFee, _ := tx.Metadata["fee"].(float64); int64(metadataFee) != fee {
+			checker.t.Errorf("transaction %s reports a fee of %v, its fee operation credits %d", tx.TransactionIdentifier.Hash, metadataFee, fee)
+		}
+	}
+	if claimed != fees {
+		checker.t.Errorf("coinbase of block %d claims %d of %d fees", block.BlockIdentifier.Index, claimed, fees)
+	}
+}
+
+// BlockAdded is called by the syncer for every new block
+func (checker *dataChecker) BlockAdded(ctx context.Context, block *types.Block) error {
+	checker.checkOperations(block)
+	if err := checker.applyChanges(ctx, block, false); err != nil {
+		return err
+	}
+
+	checker.blocks[block.BlockIdentifier.Hash] = block
+	checker.head = block.BlockIdentifier
+	return nil
+}
+
+// BlockRemoved is called by the syncer for every orphaned block
+func (checker *dataChecker) BlockRemoved(ctx context.Context, blockIdentifier *types.BlockIdentifier) error {
+	block := checker.blocks[blockIdentifier.Hash]
+	if err := checker.applyChanges(ctx, block, true); err != nil {
+		return err
+	}
+
+	delete(checker.blocks, blockIdentifier.Hash)
+	checker.head = block.ParentBlockIdentifier
+	checker.removed++
+	return nil
+}
+
+// BlockExists is used by the reconciler to detect orphaned blocks
+func (checker *dataChecker) BlockExists(ctx context.Context, block *types.BlockIdentifier) (bool, error) {
+	_, ok := checker.blocks[block.Hash]
+	return ok, nil
+}
+
+// CurrentBlock is the latest synced block
+func (checker *dataChecker) CurrentBlock(ctx context.Context) (*types.BlockIdentifier, error) {
+	return checker.head, nil
+}
+
+// AccountBalance returns the balance computed from the synced operations
+func (checker *dataChecker) AccountBalance(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	headBlock *types.BlockIdentifier,
+) (*types.Amount, *types.BlockIdentifier, error) {
+	return &types.Amount{
+		Value:    checker.balances[balanceKey(account, currency)],
+		Currency: currency,
+	}, headBlock, nil
+}
+
+func (checker *dataChecker) sync(ctx context.Context, start int64, end int64) {
+	if err := checker.syncer.Sync(ctx, start, end); err != nil {
+		checker.t.Fatalf("unable to sync blocks %d to %d: %v", start, end, err)
+	}
+}
+
+// reconcileHistorical compares every computed balance, pseudo-accounts
+// included, with the one replayed by the indexer at the synced head
+func (checker *dataChecker) reconcileHistorical(ctx context.Context) {
+	head := &types.PartialBlockIdentifier{Hash: &checker.head.Hash}
+	for key, entry := range checker.accounts {
+		block, balances, _, err := checker.fetcher.AccountBalanceRetry(ctx, checker.network, entry.Account, head)
+		if err != nil {
+			checker.t.Fatalf("unable to get balance of %s at block %d: %v", entry.Account.Address, checker.head.Index, err)
+		}
+		if block.Index != checker.head.Index {
+			checker.t.Errorf("balance of %s requested at block %d, got block %d", entry.Account.Address, checker.head.Index, block.Index)
+		}
+		amount, err := reconciler.ExtractAmount(balances, entry.Currency)
+		if err != nil {
+			checker.t.Fatalf("no %s balance for %s: %v", entry.Currency.Symbol, entry.Account.Address, err)
+		}
+		if amount.Value != checker.balances[key] {
+			checker.t.Errorf("balance of %s at block %d is %s, computed %s", entry.Account.Address, checker.head.Index, amount.Value, checker.balances[key])
+		}
+	}
+
+	beyond := checker.head.Index + 1
+	account := &types.AccountIdentifier{Address: alice}
+	if _, _, _, err := checker.fetcher.AccountBalance(ctx, checker.network, account, &types.PartialBlockIdentifier{Index: &beyond}); err == nil {
+		checker.t.Errorf("balance at block %d beyond the indexed tip did not fail", beyond)
+	}
+}
+
+// probe gets one of the health endpoints
+func (checker *dataChecker) probe(path string) (int, *health.Response) {
+	resp, err := http.Get(checker.url + path)
+	if err != nil {
+		checker.t.Fatalf("unable to get %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	response := &health.Response{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		checker.t.Fatalf("unable to decode %s response: %v", path, err)
+	}
+	return resp.StatusCode, response
+}
+
+// sample returns the value of an unlabelled series in scraped metrics, a
+// series that has not been exported yet is zero
+func sample(t *testing.T, scraped string, series string) float64 {
+	for _, line := range strings.Split(scraped, "\n") {
+		if !strings.HasPrefix(line, series+" ") {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimPrefix(line, series+" "), 64)
+		if err != nil {
+			t.Fatalf("unable to parse %q: %v", line, err)
+		}
+		return value
+	}
+
+	return 0
+}
+
+// metrics scrapes the gateway metrics
+func (checker *dataChecker) metrics() string {
+	request, err := http.NewRequest(http.MethodGet, checker.url+"/metrics", nil)
+	if err != nil {
+		checker.t.Fatalf("unable to create metrics request: %v", err)
+	}
+	request.Header.Set(logging.RequestIDHeader, "metrics")
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		checker.t.Fatalf("unable to get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	if requestID := resp.Header.Get(logging.RequestIDHeader); requestID != "metrics" {
+		checker.t.Errorf("request ID %q was not echoed", requestID)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		checker.t.Fatalf("unable to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+// reconcile compares every computed balance with the one reported by the
+// gateway, pseudo-accounts are checked by the caller since the node has no
+// balance for them
+func (checker *dataChecker) reconcile(ctx context.Context) {
+	for _, entry := range checker.accounts {
+		if client.IsPseudoAddress(entry.Account.Address) {
+			continue
+		}
+
+		liveBlock, balances, _, err := checker.fetcher.AccountBalanceRetry(ctx, checker.network, entry.Account, nil)
+		if err != nil {
+			checker.t.Fatalf("unable to get balance of %s: %v", entry.Account.Address, err)
+		}
+		amount, err := reconciler.ExtractAmount(balances, entry.Currency)
+		if err != nil {
+			checker.t.Fatalf("no %s balance for %s: %v", entry.Currency.Symbol, entry.Account.Address, err)
+		}
+
+		difference, computed, _, err := checker.reconciler.CompareBalance(ctx, entry.Account, entry.Currency, amount.Value, liveBlock)
+		if err != nil {
+			checker.t.Fatalf("unable to reconcile %s: %v", entry.Account.Address, err)
+		}
+		if difference != "0" {
+			checker.t.Errorf("balance of %s at block %d is %s, computed %s", entry.Account.Address, liveBlock.Index, amount.Value, computed)
+		}
+	}
+}
+
+func TestCheckData(t *testing.T) {
+	node := zcoindtest.NewNode()
+	node.SetMinerAddress(alice)
+	node.Mine()
+	node.Mine()
+
+	// transfers paying fees, including an output spent in its own block
+	coinbase := node.BlockAt(1).Tx[0]
+	payment := zcoindtest.Transaction(
+		[]client.Vin{zcoindtest.Input(&coinbase, 0)},
+		zcoindtest.Output(bob, 20.29),
+		zcoindtest.Output(alice, 29.7),
+	)
+	node.AddToMempool(payment)
+	forward := zcoindtest.Transaction(
+		[]client.Vin{zcoindtest.Input(payment, 0)},
+		zcoindtest.Output(carol, 0.29),
+		zcoindtest.Output(bob, 19.99),
+	)
+	node.AddToMempool(forward)
+
+	// alice locks a Znode collateral paying out to carol
+	node.RegisterZnode(payment, 1, client.ZnodeInfo{
+		ProTxHash:         "4d2b7f9e3c1a5b6d8e0f2a4c6e8b0d2f4a6c8e0b2d4f6a8c0e2b4d6f8a0c2e4b",
+		Status:            "ENABLED",
+		Payee:             carol,
+		CollateralAddress: alice,
+	})
+
+	// an Elysium issuance and a send rejected by the token layer
+	node.AddElysiumTransaction(payment, client.ElysiumTransaction{
+		SendingAddress: alice,
+		TypeInt:        client.ELYSIUM_CREATE_FIXED,
+		Type:           "Create Property - Fixed",
+		PropertyID:     3,
+		Divisible:      true,
+		Amount:         "1000.00000000",
+		Valid:          true,
+	})
+	node.AddElysiumTransaction(forward, client.ElysiumTransaction{
+		SendingAddress:   bob,
+		ReferenceAddress: carol,
+		TypeInt:          client.ELYSIUM_SIMPLE_SEND,
+		Type:             "Simple Send",
+		PropertyID:       3,
+		Divisible:        true,
+		Amount:           "5.00000000",
+		Valid:            false,
+		InvalidReason:    "Sender has insufficient balance",
+	})
+	node.SetMinerAddress(carol)
+	node.Mine()
+	node.Mine()
+
+	// value entering and leaving a multisig script and the privacy pools
+	sharedFunds := node.BlockAt(3).Tx[0]
+	shared := zcoindtest.Transaction(
+		[]client.Vin{zcoindtest.Input(&sharedFunds, 0)},
+		zcoindtest.Multisig(30, alice, bob),
+		zcoindtest.NullData([]byte("zcoin")),
+		zcoindtest.Output(carol, 19.99),
+	)
+	node.AddToMempool(shared)
+	mintFunds := node.BlockAt(2).Tx[0]
+	node.AddToMempool(zcoindtest.Transaction(
+		[]client.Vin{zcoindtest.Input(&mintFunds, 0)},
+		zcoindtest.SigmaMint(10),
+		zcoindtest.LelantusMint(20),
+		zcoindtest.Output(alice, 19.9),
+	))
+	node.Mine()
+	tokenSend := zcoindtest.Transaction(
+		[]client.Vin{zcoindtest.Input(shared, 0)},
+		zcoindtest.Output(alice, 29.99),
+	)
+	node.AddToMempool(tokenSend)
+	node.AddElysiumTransaction(tokenSend, client.ElysiumTransaction{
+		SendingAddress:   alice,
+		ReferenceAddress: bob,
+		TypeInt:          client.ELYSIUM_SIMPLE_SEND,
+		Type:             "Simple Send",
+		PropertyID:       3,
+		Divisible:        true,
+		Amount:           "250.50000000",
+		Valid:            true,
+	})
+	node.AddToMempool(zcoindtest.Transaction(
+		[]client.Vin{zcoindtest.SigmaSpend(10)},
+		zcoindtest.Output(bob, 9.99),
+	))
+	node.AddToMempool(zcoindtest.Transaction(
+		[]client.Vin{zcoindtest.JoinSplit(0.01)},
+		zcoindtest.Output(carol, 5),
+		zcoindtest.LelantusJMint(),
+	))
+	node.Mine()
+
+	stub := zcoindtest.NewServer(node)
+	defer stub.Close()
+	cfg := stub.Config()
+	cfg.Elysium.Enabled = true
+	// blocks below the depth of the reorg are final
+	cfg.Cache.ConfirmationDepth = 2
+	logger := zaptest.NewLogger(t)
+	zcoinClient := client.NewZcoinClient(cfg, logger)
+
+	db, err := zcoindtest.NewDatabase()
+	if err != nil {
+		t.Fatalf("unable to open database: %v", err)
+	}
+	defer db.Close()
+	blocks := repository.NewBlockProvider(db.BadgerDB)
+	znodes := mapper.NewZnodeCache()
+	idx := indexer.New(zcoinClient, blocks, indexer.NewMempool(), znodes, 0, logger)
+	blockCache := mapper.NewBlockResponseCache(cfg.Cache.Size, cfg.Cache.ConfirmationDepth)
+	idx.AddListener(blockCache)
+	hub := subscription.NewHub(0, logger)
+	webhooks := repository.NewWebhookProvider(db.BadgerDB)
+
+	ctx := context.Background()
+	genesis, err := services.VerifyGenesisBlock(ctx, zcoinClient)
+	if err != nil {
+		t.Fatalf("unable to verify genesis block: %v", err)
+	}
+
+	gateway := httptest.NewServer(NewBlockchainRouter(zcoinClient, blocks, znodes, blockCache, webhooks, hub, genesis, logger))
+	defer gateway.Close()
+
+	checker := newDataChecker(ctx, t, gateway.URL)
+
+	height := node.Height()
+	checker.sync(ctx, -1, height)
+	checker.reconcile(ctx)
+	if err := idx.Sync(ctx); err != nil {
+		t.Fatalf("unable to index blocks: %v", err)
+	}
+	checker.reconcileHistorical(ctx)
+
+	// the counters are global, only the increase caused by the reorg is checked
+	before := checker.metrics()
+
+	// orphan the privacy transactions, they return to the mempool and are mined again
+	node.Reorg(2)
+	node.SetMinerAddress(bob)
+	node.Mine()
+	node.Mine()
+	node.Mine()
+
+	// resume from the previous tip so the syncer walks back over the orphans
+	checker.sync(ctx, height, node.Height())
+	checker.reconcile(ctx)
+	if err := idx.Sync(ctx); err != nil {
+		t.Fatalf("unable to index blocks: %v", err)
+	}
+	checker.reconcileHistorical(ctx)
+
+	pseudoAccounts := map[string]string{
+		client.SIGMA_POOL_ADDRESS:                          "0",
+		client.FEE_POOL_ADDRESS:                            "0",
+		client.LELANTUS_POOL_ADDRESS:                       "1499000000",
+		client.NULL_DATA_ADDRESS:                           "0",
+		client.ScriptAddress(&shared.Vout[0].ScriptPubKey): "0",
+	}
+	for address, expected := range pseudoAccounts {
+		account := &types.AccountIdentifier{Address: address}
+		if balance := checker.balances[balanceKey(account, mapper.Currency)]; balance != expected {
+			t.Errorf("%s balance is %s, expected %s", address, balance, expected)
+		}
+	}
+
+	tokens := &types.AccountIdentifier{Address: bob}
+	if balance := checker.balances[balanceKey(tokens, mapper.ElysiumCurrency(3, true))]; balance != "25050000000" {
+		t.Errorf("%s has %s units of property 3, expected 25050000000", bob, balance)
+	}
+
+	collateral := mapper.Outpoint(payment.Txid, 1)
+	var annotated, payouts int
+	for _, block := range checker.blocks {
+		for _, tx := range block.Transactions {
+			for _, op := range tx.Operations {
+				if znode, ok := op.Metadata["znode"].(map[string]interface{}); ok && znode["collateral"] == collateral {
+					annotated++
+				}
+				if _, ok := op.Metadata["znodePayouts"]; ok {
+					payouts++
+				}
+			}
+		}
+	}
+	if annotated != 1 || payouts == 0 {
+		t.Errorf("expected the collateral and the payouts to be annotated, got %d and %d", annotated, payouts)
+	}
+
+	tip := checker.blocks[checker.head.Hash]
+	if tip.Metadata["txCount"] != float64(len(tip.Transactions)) || tip.Metadata["mtpHashValue"] == nil {
+		t.Errorf("block %d metadata is incomplete: %v", tip.BlockIdentifier.Index, tip.Metadata)
+	}
+	if cbTx, ok := tip.Transactions[0].Metadata["cbTx"].(map[string]interface{}); !ok || cbTx["height"] != float64(tip.BlockIdentifier.Index) {
+		t.Errorf("coinbase of block %d has no CbTx payload: %v", tip.BlockIdentifier.Index, tip.Transactions[0].Metadata)
+	}
+
+	if checker.removed != 2 {
+		t.Errorf("expected 2 orphaned blocks, got %d", checker.removed)
+	}
+	if checker.head.Hash != node.BlockAt(node.Height()).Hash {
+		t.Errorf("synced to %s, node tip is %s", checker.head.Hash, node.BlockAt(node.Height()).Hash)
+	}
+
+	if status, response := checker.probe("/readyz"); status != http.StatusOK {
+		for name, component := range response.Components {
+			t.Errorf("%s is not ready: %+v", name, component)
+		}
+	}
+
+	// the requests, node calls, indexer progress and the reorg are exposed
+	scraped := checker.metrics()
+	for _, series := range []string{
+		`zcoin_rosetta_requests_total{route="Block"}`,
+		`zcoin_rosetta_request_duration_seconds_count{route="NetworkStatus"}`,
+		`zcoin_rosetta_rpc_duration_seconds_count{method="GetBlockByHashWithTransaction"}`,
+		fmt.Sprintf("zcoin_rosetta_node_height %d", node.Height()),
+		fmt.Sprintf("zcoin_rosetta_indexer_height %d", node.Height()),
+	} {
+		if !strings.Contains(scraped, series) {
+			t.Errorf("metrics are missing %s", series)
+		}
+	}
+	for series, increase := range map[string]float64{
+		"zcoin_rosetta_indexer_reorgs_total":          1,
+		"zcoin_rosetta_indexer_orphaned_blocks_total": 2,
+	} {
+		if got := sample(t, scraped, series) - sample(t, before, series); got != increase {
+			t.Errorf("%s increased by %v, expected %v", series, got, increase)
+		}
+	}
+}
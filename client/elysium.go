@@ -0,0 +1,92 @@
+package client
+
+// Elysium transaction types handled by the gateway
+const (
+	ELYSIUM_SIMPLE_SEND     = 0
+	ELYSIUM_SEND_TO_OWNERS  = 3
+	ELYSIUM_SEND_ALL        = 4
+	ELYSIUM_CREATE_FIXED    = 50
+	ELYSIUM_GRANT_TOKENS    = 55
+	ELYSIUM_REVOKE_TOKENS   = 56
+	ELYSIUM_DIVISIBLE_UNITS = 8
+)
+
+// Elysium transaction types that move no tokens
+const (
+	ELYSIUM_CREATE_VARIABLE  = 51
+	ELYSIUM_CLOSE_CROWDSALE  = 53
+	ELYSIUM_CREATE_MANAGED   = 54
+	ELYSIUM_CHANGE_ISSUER    = 70
+	ELYSIUM_ENABLE_FREEZING  = 71
+	ELYSIUM_DISABLE_FREEZING = 72
+	ELYSIUM_FREEZE_TOKENS    = 185
+	ELYSIUM_UNFREEZE_TOKENS  = 186
+)
+
+// Send to owners fees are paid with the native token of the ecosystem of the
+// property sent, properties from ELYSIUM_TEST_ECOSYSTEM on are test ones
+const (
+	ELYSIUM_MAIN_TOKEN     = 1
+	ELYSIUM_TEST_TOKEN     = 2
+	ELYSIUM_TEST_ECOSYSTEM = 2147483651
+)
+
+// ElysiumTransaction models the data from the elysium_gettransaction command,
+// amounts are decimal strings with 8 decimals for divisible properties. Send
+// all transactions list their transfers in SubSends and crowdsale purchases
+// are simple sends carrying the purchased tokens. The recipients of a send to
+// owners are only returned by elysium_getsto.
+type ElysiumTransaction struct {
+	Txid                       string             `json:"txid"`
+	SendingAddress             string             `json:"sendingaddress"`
+	ReferenceAddress           string             `json:"referenceaddress,omitempty"`
+	Version                    int64              `json:"version"`
+	TypeInt                    int64              `json:"type_int"`
+	Type                       string             `json:"type"`
+	PropertyID                 int64              `json:"propertyid"`
+	Divisible                  bool               `json:"divisible"`
+	Amount                     string             `json:"amount"`
+	SubSends                   []ElysiumSubSend   `json:"subsends,omitempty"`
+	PurchasedPropertyID        int64              `json:"purchasedpropertyid,omitempty"`
+	PurchasedPropertyDivisible bool               `json:"purchasedpropertydivisible,omitempty"`
+	PurchasedTokens            string             `json:"purchasedtokens,omitempty"`
+	IssuerTokens               string             `json:"issuertokens,omitempty"`
+	TotalSTOFee                string             `json:"totalstofee,omitempty"`
+	Recipients                 []ElysiumRecipient `json:"recipients,omitempty"`
+	Valid                      bool               `json:"valid"`
+	InvalidReason              string             `json:"invalidreason,omitempty"`
+	BlockHash                  string             `json:"blockhash,omitempty"`
+	Block                      int64              `json:"block,omitempty"`
+	Confirmations              int64              `json:"confirmations"`
+}
+
+// ElysiumSubSend is one of the transfers of a send all transaction
+type ElysiumSubSend struct {
+	PropertyID int64  `json:"propertyid"`
+	Divisible  bool   `json:"divisible"`
+	Amount     string `json:"amount"`
+}
+
+// ElysiumRecipient is a token owner credited by a send to owners transaction
+type ElysiumRecipient struct {
+	Address string `json:"address"`
+	Amount  string `json:"amount"`
+}
+
+// ElysiumBalance models an entry of the elysium_getallbalancesforaddress
+// command, divisible balances are formatted with 8 decimals
+type ElysiumBalance struct {
+	PropertyID int64  `json:"propertyid"`
+	Name       string `json:"name,omitempty"`
+	Balance    string `json:"balance"`
+	Reserved   string `json:"reserved"`
+}
+
+// ElysiumProperty models the data from the elysium_getproperty command
+type ElysiumProperty struct {
+	PropertyID  int64  `json:"propertyid"`
+	Name        string `json:"name"`
+	Issuer      string `json:"issuer"`
+	Divisible   bool   `json:"divisible"`
+	TotalTokens string `json:"totaltokens"`
+}
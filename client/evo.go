@@ -0,0 +1,262 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/pkg/errors"
+)
+
+// Evo special transaction types, carried in the upper 16 bits of the version
+const (
+	TRANSACTION_NORMAL        = 0
+	PROVIDER_REGISTER         = 1
+	PROVIDER_UPDATE_SERVICE   = 2
+	PROVIDER_UPDATE_REGISTRAR = 3
+	PROVIDER_UPDATE_REVOKE    = 4
+	COINBASE                  = 5
+	QUORUM_COMMITMENT         = 6
+)
+
+var specialTxNames = map[int32]string{
+	TRANSACTION_NORMAL:        "normal",
+	PROVIDER_REGISTER:         "ProRegTx",
+	PROVIDER_UPDATE_SERVICE:   "ProUpServTx",
+	PROVIDER_UPDATE_REGISTRAR: "ProUpRegTx",
+	PROVIDER_UPDATE_REVOKE:    "ProUpRevTx",
+	COINBASE:                  "CbTx",
+	QUORUM_COMMITMENT:         "QcTx",
+}
+
+// SpecialTxName returns the name of a special transaction type
+func SpecialTxName(txType int32) string {
+	if name, ok := specialTxNames[txType]; ok {
+		return name
+	}
+
+	return "type" + strconv.Itoa(int(txType))
+}
+
+// ProRegTx registers a deterministic Znode
+type ProRegTx struct {
+	Version         uint16  `json:"version"`
+	CollateralType  uint16  `json:"collateralType"`
+	Mode            uint16  `json:"mode"`
+	CollateralHash  string  `json:"collateralHash"`
+	CollateralIndex uint32  `json:"collateralIndex"`
+	Service         string  `json:"service"`
+	OwnerAddress    string  `json:"ownerAddress"`
+	PubKeyOperator  string  `json:"pubKeyOperator"`
+	VotingAddress   string  `json:"votingAddress"`
+	OperatorReward  float64 `json:"operatorReward"`
+	PayoutAddress   string  `json:"payoutAddress"`
+	InputsHash      string  `json:"inputsHash"`
+}
+
+// ProUpServTx updates the service address of a Znode
+type ProUpServTx struct {
+	Version               uint16 `json:"version"`
+	ProTxHash             string `json:"proTxHash"`
+	Service               string `json:"service"`
+	OperatorPayoutAddress string `json:"operatorPayoutAddress,omitempty"`
+	InputsHash            string `json:"inputsHash"`
+}
+
+// ProUpRegTx updates the operator, voting key and payout of a Znode
+type ProUpRegTx struct {
+	Version        uint16 `json:"version"`
+	ProTxHash      string `json:"proTxHash"`
+	Mode           uint16 `json:"mode"`
+	PubKeyOperator string `json:"pubKeyOperator"`
+	VotingAddress  string `json:"votingAddress"`
+	PayoutAddress  string `json:"payoutAddress"`
+	InputsHash     string `json:"inputsHash"`
+}
+
+// ProUpRevTx revokes a Znode
+type ProUpRevTx struct {
+	Version    uint16 `json:"version"`
+	ProTxHash  string `json:"proTxHash"`
+	Reason     uint16 `json:"reason"`
+	InputsHash string `json:"inputsHash"`
+}
+
+// CbTx commits the Znode list and quorums in the coinbase
+type CbTx struct {
+	Version           uint16 `json:"version"`
+	Height            int32  `json:"height"`
+	MerkleRootMNList  string `json:"merkleRootMNList"`
+	MerkleRootQuorums string `json:"merkleRootQuorums,omitempty"`
+}
+
+const blsPublicKeySize = 48
+
+// payloadReader decodes a payload field by field, the first failure is kept
+// and every later read is skipped
+type payloadReader struct {
+	*bytes.Reader
+	params *chaincfg.Params
+	err    error
+}
+
+func (r *payloadReader) read(value interface{}) {
+	if r.err == nil {
+		r.err = binary.Read(r, binary.LittleEndian, value)
+	}
+}
+
+func (r *payloadReader) uint16() (value uint16) {
+	r.read(&value)
+	return
+}
+
+func (r *payloadReader) uint32() (value uint32) {
+	r.read(&value)
+	return
+}
+
+func (r *payloadReader) bytes(size int) []byte {
+	buf := make([]byte, size)
+	if r.err == nil {
+		_, r.err = io.ReadFull(r, buf)
+	}
+	return buf
+}
+
+func (r *payloadReader) hash() string {
+	hash, err := chainhash.NewHash(r.bytes(chainhash.HashSize))
+	if err != nil {
+		return ""
+	}
+
+	return hash.String()
+}
+
+// service reads an IPv6 mapped address followed by a big endian port
+func (r *payloadReader) service() string {
+	ip := r.bytes(net.IPv6len)
+	port := r.bytes(2)
+
+	return net.JoinHostPort(net.IP(ip).String(), strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+}
+
+func (r *payloadReader) keyID() string {
+	address, err := btcutil.NewAddressPubKeyHash(r.bytes(20), r.params)
+	if err != nil {
+		return ""
+	}
+
+	return address.EncodeAddress()
+}
+
+func (r *payloadReader) blsPublicKey() string {
+	return hex.EncodeToString(r.bytes(blsPublicKeySize))
+}
+
+// script reads a script and returns the address it pays to, or its hex
+func (r *payloadReader) script() string {
+	var size uint64
+	if r.err == nil {
+		size, r.err = wire.ReadVarInt(r, 0)
+	}
+	if r.err == nil && size > uint64(r.Len()) {
+		r.err = errors.Errorf("script of %d bytes exceeds payload", size)
+	}
+	if r.err != nil || size == 0 {
+		return ""
+	}
+
+	script := r.bytes(int(size))
+	_, addresses, _, err := txscript.ExtractPkScriptAddrs(script, r.params)
+	if err != nil || len(addresses) != 1 {
+		return hex.EncodeToString(script)
+	}
+
+	return addresses[0].EncodeAddress()
+}
+
+// DecodeSpecialTx decodes the extra payload of an evo special transaction
+// into its typed form, addresses are encoded for the given network. Quorum
+// commitments and unknown types are not decoded, nil is returned for them.
+func DecodeSpecialTx(txType int32, payload string, params *chaincfg.Params) (interface{}, error) {
+	raw, err := hex.DecodeString(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid extra payload")
+	}
+	r := &payloadReader{Reader: bytes.NewReader(raw), params: params}
+
+	var decoded interface{}
+	switch txType {
+	case PROVIDER_REGISTER:
+		decoded = &ProRegTx{
+			Version:         r.uint16(),
+			CollateralType:  r.uint16(),
+			Mode:            r.uint16(),
+			CollateralHash:  r.hash(),
+			CollateralIndex: r.uint32(),
+			Service:         r.service(),
+			OwnerAddress:    r.keyID(),
+			PubKeyOperator:  r.blsPublicKey(),
+			VotingAddress:   r.keyID(),
+			OperatorReward:  float64(r.uint16()) / 100,
+			PayoutAddress:   r.script(),
+			InputsHash:      r.hash(),
+		}
+
+	case PROVIDER_UPDATE_SERVICE:
+		decoded = &ProUpServTx{
+			Version:               r.uint16(),
+			ProTxHash:             r.hash(),
+			Service:               r.service(),
+			OperatorPayoutAddress: r.script(),
+			InputsHash:            r.hash(),
+		}
+
+	case PROVIDER_UPDATE_REGISTRAR:
+		decoded = &ProUpRegTx{
+			Version:        r.uint16(),
+			ProTxHash:      r.hash(),
+			Mode:           r.uint16(),
+			PubKeyOperator: r.blsPublicKey(),
+			VotingAddress:  r.keyID(),
+			PayoutAddress:  r.script(),
+			InputsHash:     r.hash(),
+		}
+
+	case PROVIDER_UPDATE_REVOKE:
+		decoded = &ProUpRevTx{
+			Version:    r.uint16(),
+			ProTxHash:  r.hash(),
+			Reason:     r.uint16(),
+			InputsHash: r.hash(),
+		}
+
+	case COINBASE:
+		cbTx := &CbTx{
+			Version:          r.uint16(),
+			Height:           int32(r.uint32()),
+			MerkleRootMNList: r.hash(),
+		}
+		if cbTx.Version >= 2 {
+			cbTx.MerkleRootQuorums = r.hash()
+		}
+		decoded = cbTx
+
+	default:
+		return nil, nil
+	}
+	if r.err != nil {
+		return nil, errors.Wrapf(r.err, "malformed %s payload", SpecialTxName(txType))
+	}
+
+	return decoded, nil
+}
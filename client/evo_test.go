@@ -0,0 +1,191 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// payloadWriter serializes payload fields the way zcoind does
+type payloadWriter struct {
+	bytes.Buffer
+}
+
+func (w *payloadWriter) write(values ...interface{}) *payloadWriter {
+	for _, value := range values {
+		switch value := value.(type) {
+		case []byte:
+			w.Write(value)
+		case chainhash.Hash:
+			w.Write(value[:])
+		default:
+			binary.Write(w, binary.LittleEndian, value)
+		}
+	}
+	return w
+}
+
+func (w *payloadWriter) service(ip string, port uint16) *payloadWriter {
+	w.Write(net.ParseIP(ip).To16())
+	binary.Write(w, binary.BigEndian, port)
+	return w
+}
+
+func (w *payloadWriter) script(script []byte) *payloadWriter {
+	wire.WriteVarInt(w, 0, uint64(len(script)))
+	w.Write(script)
+	return w
+}
+
+func (w *payloadWriter) hex() string {
+	return hex.EncodeToString(w.Bytes())
+}
+
+func TestDecodeSpecialTx(t *testing.T) {
+	params := ChainParams(REGTEST)
+	proTxHash := chainhash.DoubleHashH([]byte("protx"))
+	inputsHash := chainhash.DoubleHashH([]byte("inputs"))
+	owner := bytes.Repeat([]byte{0x01}, 20)
+	voting := bytes.Repeat([]byte{0x02}, 20)
+	payout := bytes.Repeat([]byte{0x03}, 20)
+	operator := bytes.Repeat([]byte{0x04}, blsPublicKeySize)
+
+	address := func(keyID []byte) string {
+		encoded, err := btcutil.NewAddressPubKeyHash(keyID, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encoded.EncodeAddress()
+	}
+	payoutScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).AddData(payout).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		txType  int32
+		payload string
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:   "ProRegTx",
+			txType: PROVIDER_REGISTER,
+			payload: (&payloadWriter{}).
+				write(uint16(1), uint16(0), uint16(0), proTxHash, uint32(1)).
+				service("192.168.1.2", 8168).
+				write(owner, operator, voting, uint16(250)).
+				script(payoutScript).
+				write(inputsHash).hex(),
+			want: &ProRegTx{
+				Version:         1,
+				CollateralHash:  proTxHash.String(),
+				CollateralIndex: 1,
+				Service:         "192.168.1.2:8168",
+				OwnerAddress:    address(owner),
+				PubKeyOperator:  hex.EncodeToString(operator),
+				VotingAddress:   address(voting),
+				OperatorReward:  2.5,
+				PayoutAddress:   address(payout),
+				InputsHash:      inputsHash.String(),
+			},
+		},
+		{
+			name:   "ProUpServTx without operator payout",
+			txType: PROVIDER_UPDATE_SERVICE,
+			payload: (&payloadWriter{}).
+				write(uint16(1), proTxHash).
+				service("10.0.0.1", 18168).
+				script(nil).
+				write(inputsHash).hex(),
+			want: &ProUpServTx{
+				Version:    1,
+				ProTxHash:  proTxHash.String(),
+				Service:    "10.0.0.1:18168",
+				InputsHash: inputsHash.String(),
+			},
+		},
+		{
+			name:   "ProUpRegTx",
+			txType: PROVIDER_UPDATE_REGISTRAR,
+			payload: (&payloadWriter{}).
+				write(uint16(1), proTxHash, uint16(0), operator, voting).
+				script(payoutScript).
+				write(inputsHash).hex(),
+			want: &ProUpRegTx{
+				Version:        1,
+				ProTxHash:      proTxHash.String(),
+				PubKeyOperator: hex.EncodeToString(operator),
+				VotingAddress:  address(voting),
+				PayoutAddress:  address(payout),
+				InputsHash:     inputsHash.String(),
+			},
+		},
+		{
+			name:   "ProUpRevTx",
+			txType: PROVIDER_UPDATE_REVOKE,
+			payload: (&payloadWriter{}).
+				write(uint16(1), proTxHash, uint16(3), inputsHash).hex(),
+			want: &ProUpRevTx{
+				Version:    1,
+				ProTxHash:  proTxHash.String(),
+				Reason:     3,
+				InputsHash: inputsHash.String(),
+			},
+		},
+		{
+			name:    "truncated ProUpRevTx",
+			txType:  PROVIDER_UPDATE_REVOKE,
+			payload: (&payloadWriter{}).write(uint16(1), proTxHash).hex(),
+			wantErr: true,
+		},
+		{
+			name:   "script exceeding the payload",
+			txType: PROVIDER_UPDATE_SERVICE,
+			payload: (&payloadWriter{}).
+				write(uint16(1), proTxHash).
+				service("10.0.0.1", 18168).
+				write(uint8(0xfc)).hex(),
+			wantErr: true,
+		},
+		{
+			name:    "quorum commitment",
+			txType:  QUORUM_COMMITMENT,
+			payload: "00",
+		},
+		{
+			name:    "unknown type",
+			txType:  9,
+			payload: "00",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := DecodeSpecialTx(test.txType, test.payload, params)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("decoded %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/pkg/errors"
+)
+
+// ErrFixtureNotFound is returned by the replay client for calls that were never recorded
+var ErrFixtureNotFound = errors.New("fixture not found")
+
+// fixture is a recorded request/response pair stored as a golden file
+type fixture struct {
+	Method    string                `json:"method"`
+	Params    []interface{}         `json:"params,omitempty"`
+	Result    json.RawMessage       `json:"result,omitempty"`
+	Error     string                `json:"error,omitempty"`
+	ErrorCode *btcjson.RPCErrorCode `json:"errorCode,omitempty"`
+}
+
+// fixturePath names golden files after the method and its parameters,
+// e.g. GetBlock_12345.json. Calls without parameters are named after their
+// sequence number instead, e.g. GetStatus_2.json for the second call.
+func fixturePath(dir string, method string, params []interface{}, sequence int) string {
+	parts := []string{method}
+	for _, param := range params {
+		parts = append(parts, fmt.Sprint(param))
+	}
+	if len(params) == 0 {
+		parts = append(parts, strconv.Itoa(sequence))
+	}
+
+	return filepath.Join(dir, strings.Join(parts, "_")+".json")
+}
+
+// fixtureSequence numbers the calls made to each method without parameters
+type fixtureSequence struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newFixtureSequence() *fixtureSequence {
+	return &fixtureSequence{
+		calls: make(map[string]int),
+	}
+}
+
+// next returns the sequence number of the next call, starting at 1
+func (s *fixtureSequence) next(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls[method]++
+	return s.calls[method]
+}
+
+// rewind makes the last call number the next one again
+func (s *fixtureSequence) rewind(method string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls[method]--
+}
+
+func writeFixture(dir string, method string, params []interface{}, sequence int, result interface{}, callErr error) error {
+	f := &fixture{
+		Method: method,
+		Params: params,
+	}
+
+	if callErr != nil {
+		f.Error = callErr.Error()
+		if rpcErr, ok := errors.Cause(callErr).(*btcjson.RPCError); ok {
+			f.Error = rpcErr.Message
+			f.ErrorCode = &rpcErr.Code
+		}
+	} else {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		f.Result = encoded
+	}
+
+	encoded, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// replays running concurrently never see a partially written fixture
+	tmp, err := ioutil.TempFile(dir, ".fixture-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), fixturePath(dir, method, params, sequence))
+}
+
+func readFixture(dir string, method string, params []interface{}, sequence int, result interface{}) error {
+	path := fixturePath(dir, method, params, sequence)
+	encoded, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return errors.Wrap(ErrFixtureNotFound, path)
+	}
+	if err != nil {
+		return err
+	}
+
+	f := &fixture{}
+	if err := json.Unmarshal(encoded, f); err != nil {
+		return errors.Wrapf(err, "malformed fixture %s", path)
+	}
+
+	if f.ErrorCode != nil {
+		return &btcjson.RPCError{Code: *f.ErrorCode, Message: f.Error}
+	}
+	if f.Error != "" {
+		return errors.New(f.Error)
+	}
+
+	return json.Unmarshal(f.Result, result)
+}
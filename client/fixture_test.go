@@ -0,0 +1,145 @@
+package client_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zcoin-fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	node := zcoindtest.NewNode()
+	stub := zcoindtest.NewServer(node)
+	defer stub.Close()
+	ctx := context.Background()
+
+	recorder := client.NewZcoinClientRecorder(client.NewZcoinClient(stub.Config(), zap.NewNop()), dir, zap.NewNop())
+	var heights []int32
+	for i := 0; i < 2; i++ {
+		status, err := recorder.GetStatus(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		heights = append(heights, status.Blocks)
+		node.Mine()
+	}
+	block, err := recorder.GetBlock(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.GetBlock(ctx, 99); err == nil {
+		t.Fatal("got block 99 of a 3 block chain")
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	want := []string{"GetBlock_1.json", "GetBlock_99.json", "GetStatus_1.json", "GetStatus_2.json"}
+	if len(names) != len(want) {
+		t.Fatalf("recorded %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("recorded %v, want %v", names, want)
+			break
+		}
+	}
+
+	replay := client.NewZcoinClientReplay(stub.Config(), dir)
+	tests := []struct {
+		name string
+		call func() (interface{}, error)
+		want interface{}
+		code *btcjson.RPCErrorCode
+		err  error
+	}{
+		{
+			name: "first status",
+			call: func() (interface{}, error) {
+				status, err := replay.GetStatus(ctx)
+				return status.Blocks, err
+			},
+			want: heights[0],
+		},
+		{
+			name: "second status",
+			call: func() (interface{}, error) {
+				status, err := replay.GetStatus(ctx)
+				return status.Blocks, err
+			},
+			want: heights[1],
+		},
+		{
+			name: "last status repeated",
+			call: func() (interface{}, error) {
+				status, err := replay.GetStatus(ctx)
+				return status.Blocks, err
+			},
+			want: heights[1],
+		},
+		{
+			name: "block",
+			call: func() (interface{}, error) {
+				block, err := replay.GetBlock(ctx, 1)
+				return block.Hash, err
+			},
+			want: block.Hash,
+		},
+		{
+			name: "recorded node error",
+			call: func() (interface{}, error) {
+				return replay.GetBlock(ctx, 99)
+			},
+			code: func() *btcjson.RPCErrorCode {
+				code := btcjson.ErrRPCInvalidParameter
+				return &code
+			}(),
+		},
+		{
+			name: "never recorded",
+			call: func() (interface{}, error) {
+				return replay.GetRawMempool(ctx)
+			},
+			err: client.ErrFixtureNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.call()
+			switch {
+			case test.code != nil:
+				rpcErr, ok := err.(*btcjson.RPCError)
+				if !ok || rpcErr.Code != *test.code {
+					t.Errorf("got %v, want RPC error %d", err, *test.code)
+				}
+			case test.err != nil:
+				if errors.Cause(err) != test.err {
+					t.Errorf("got %v, want %v", err, test.err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case got != test.want:
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/logging"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/metrics"
+)
+
+// ZcoinClientMetrics is an implementation of ZcoinClient that forwards every
+// call and observes its latency and failures per method, every call is logged
+// at debug level and failures at warn level
+type ZcoinClientMetrics struct {
+	client ZcoinClient
+	logger *zap.Logger
+}
+
+// NewZcoinClientMetrics returns a ZcoinClient observing every call to client
+func NewZcoinClientMetrics(client ZcoinClient, logger *zap.Logger) ZcoinClient {
+	return &ZcoinClientMetrics{
+		client: client,
+		logger: logger,
+	}
+}
+
+func (observer *ZcoinClientMetrics) observe(ctx context.Context, method string, start time.Time, err error) {
+	duration := time.Since(start)
+	logger := logging.FromContext(ctx, observer.logger)
+	metrics.RPCDuration.WithLabelValues(method).Observe(duration.Seconds())
+	if err != nil {
+		metrics.RPCErrors.WithLabelValues(method).Inc()
+		logger.Warn("node call failed", zap.String("method", method), zap.Duration("duration", duration), zap.Error(err))
+		return
+	}
+	logger.Debug("node call", zap.String("method", method), zap.Duration("duration", duration))
+}
+
+// GetConfig retrieves the general application config that has been configured
+func (observer *ZcoinClientMetrics) GetConfig() *configuration.Config {
+	return observer.client.GetConfig()
+}
+
+// GetStatus will return the Blockchain base info based on that node
+func (observer *ZcoinClientMetrics) GetStatus(ctx context.Context) (*btcjson.GetBlockChainInfoResult, error) {
+	start := time.Now()
+	result, err := observer.client.GetStatus(ctx)
+	observer.observe(ctx, "GetStatus", start, err)
+	return result, err
+}
+
+// GetBlock will return you the block specification for a given height
+func (observer *ZcoinClientMetrics) GetBlock(ctx context.Context, height int64) (*btcjson.GetBlockVerboseResult, error) {
+	start := time.Now()
+	result, err := observer.client.GetBlock(ctx, height)
+	observer.observe(ctx, "GetBlock", start, err)
+	return result, err
+}
+
+// GetBlockByHash will return you the block specification for a given hash
+func (observer *ZcoinClientMetrics) GetBlockByHash(ctx context.Context, hash string) (*btcjson.GetBlockVerboseResult, error) {
+	start := time.Now()
+	result, err := observer.client.GetBlockByHash(ctx, hash)
+	observer.observe(ctx, "GetBlockByHash", start, err)
+	return result, err
+}
+
+// GetLatestBlock returns the latest Zcoin block.
+func (observer *ZcoinClientMetrics) GetLatestBlock(ctx context.Context) (*wire.MsgBlock, error) {
+	start := time.Now()
+	result, err := observer.client.GetLatestBlock(ctx)
+	observer.observe(ctx, "GetLatestBlock", start, err)
+	return result, err
+}
+
+// GetBlockByHashWithTransaction returns the Zcoin block including transactions
+func (observer *ZcoinClientMetrics) GetBlockByHashWithTransaction(ctx context.Context, hash string) (*GetBlockVerboseTxResult, error) {
+	start := time.Now()
+	result, err := observer.client.GetBlockByHashWithTransaction(ctx, hash)
+	observer.observe(ctx, "GetBlockByHashWithTransaction", start, err)
+	return result, err
+}
+
+// GetRawTransaction returns the transaction with the given hash
+func (observer *ZcoinClientMetrics) GetRawTransaction(ctx context.Context, hash string) (*TxRawResult, error) {
+	start := time.Now()
+	result, err := observer.client.GetRawTransaction(ctx, hash)
+	observer.observe(ctx, "GetRawTransaction", start, err)
+	return result, err
+}
+
+// GetAddressBalance returns the balance of an address
+func (observer *ZcoinClientMetrics) GetAddressBalance(ctx context.Context, address string) (*AddressBalanceResult, error) {
+	start := time.Now()
+	result, err := observer.client.GetAddressBalance(ctx, address)
+	observer.observe(ctx, "GetAddressBalance", start, err)
+	return result, err
+}
+
+// GetRawMempool returns the hashes of the transactions currently in the mempool
+func (observer *ZcoinClientMetrics) GetRawMempool(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	result, err := observer.client.GetRawMempool(ctx)
+	observer.observe(ctx, "GetRawMempool", start, err)
+	return result, err
+}
+
+// ListElysiumBlockTransactions returns the Elysium transactions of a block
+func (observer *ZcoinClientMetrics) ListElysiumBlockTransactions(ctx context.Context, height int64) ([]string, error) {
+	start := time.Now()
+	result, err := observer.client.ListElysiumBlockTransactions(ctx, height)
+	observer.observe(ctx, "ListElysiumBlockTransactions", start, err)
+	return result, err
+}
+
+// GetElysiumTransaction returns the Elysium payload of a transaction
+func (observer *ZcoinClientMetrics) GetElysiumTransaction(ctx context.Context, hash string) (*ElysiumTransaction, error) {
+	start := time.Now()
+	result, err := observer.client.GetElysiumTransaction(ctx, hash)
+	observer.observe(ctx, "GetElysiumTransaction", start, err)
+	return result, err
+}
+
+// GetElysiumSTO returns the Elysium payload of a send to owners transaction
+func (observer *ZcoinClientMetrics) GetElysiumSTO(ctx context.Context, hash string) (*ElysiumTransaction, error) {
+	start := time.Now()
+	result, err := observer.client.GetElysiumSTO(ctx, hash)
+	observer.observe(ctx, "GetElysiumSTO", start, err)
+	return result, err
+}
+
+// GetElysiumBalances returns the Elysium token balances of an address
+func (observer *ZcoinClientMetrics) GetElysiumBalances(ctx context.Context, address string) ([]ElysiumBalance, error) {
+	start := time.Now()
+	result, err := observer.client.GetElysiumBalances(ctx, address)
+	observer.observe(ctx, "GetElysiumBalances", start, err)
+	return result, err
+}
+
+// GetElysiumProperty returns an Elysium property
+func (observer *ZcoinClientMetrics) GetElysiumProperty(ctx context.Context, propertyID int64) (*ElysiumProperty, error) {
+	start := time.Now()
+	result, err := observer.client.GetElysiumProperty(ctx, propertyID)
+	observer.observe(ctx, "GetElysiumProperty", start, err)
+	return result, err
+}
+
+// GetZnodeList returns the deterministic Znode list registered at given height
+func (observer *ZcoinClientMetrics) GetZnodeList(ctx context.Context, height int64) (map[string]ZnodeInfo, error) {
+	start := time.Now()
+	result, err := observer.client.GetZnodeList(ctx, height)
+	observer.observe(ctx, "GetZnodeList", start, err)
+	return result, err
+}
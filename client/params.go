@@ -0,0 +1,71 @@
+package client
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Zcoin networks as named in the network identifier
+const (
+	MAINNET = "mainnet"
+	TESTNET = "testnet"
+	REGTEST = "regtest"
+)
+
+var (
+	mainNetParams = &chaincfg.Params{
+		Name:             MAINNET,
+		PubKeyHashAddrID: 82,
+		ScriptHashAddrID: 7,
+	}
+	testNetParams = &chaincfg.Params{
+		Name:             TESTNET,
+		PubKeyHashAddrID: 65,
+		ScriptHashAddrID: 178,
+	}
+	regTestParams = &chaincfg.Params{
+		Name:             REGTEST,
+		PubKeyHashAddrID: 65,
+		ScriptHashAddrID: 178,
+	}
+)
+
+// ChainParams returns the address encoding of a Zcoin network, unknown
+// networks fall back to mainnet
+func ChainParams(network string) *chaincfg.Params {
+	switch network {
+	case TESTNET:
+		return testNetParams
+	case REGTEST:
+		return regTestParams
+	default:
+		return mainNetParams
+	}
+}
+
+// genesisHashes are the genesis block hashes of the Zcoin networks
+var genesisHashes = map[string]string{
+	MAINNET: "4381deb85b1b2c9843c222944b616d997516dcbd6a964e1eaf0def0830695233",
+	TESTNET: "aa22adcc12becaf436027ffe62a8fb21b234c58c23865291e5dc52cf53f64fca",
+	REGTEST: "a42b98f04cc2916e8adfb5d9db8a2227c4629bc205748ed2f33180b636ee885b",
+}
+
+// chainNames are the chain names zcoind reports for the Zcoin networks
+var chainNames = map[string]string{
+	MAINNET: "main",
+	TESTNET: "test",
+	REGTEST: "regtest",
+}
+
+// ChainName returns the chain name zcoind reports for a Zcoin network, ok is
+// false for unknown networks
+func ChainName(network string) (name string, ok bool) {
+	name, ok = chainNames[network]
+	return
+}
+
+// GenesisHash returns the genesis block hash of a Zcoin network, ok is false
+// for unknown networks
+func GenesisHash(network string) (hash string, ok bool) {
+	hash, ok = genesisHashes[network]
+	return
+}
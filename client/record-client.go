@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+// ZcoinClientRecorder is an implementation of ZcoinClient that forwards every
+// call and saves the request/response pair as a golden file for ZcoinClientReplay
+type ZcoinClientRecorder struct {
+	client   ZcoinClient
+	dir      string
+	sequence *fixtureSequence
+	logger   *zap.Logger
+}
+
+// NewZcoinClientRecorder returns a ZcoinClient recording every call into dir
+func NewZcoinClientRecorder(client ZcoinClient, dir string, logger *zap.Logger) ZcoinClient {
+	return &ZcoinClientRecorder{
+		client:   client,
+		dir:      dir,
+		sequence: newFixtureSequence(),
+		logger:   logger,
+	}
+}
+
+func (recorder *ZcoinClientRecorder) record(method string, params []interface{}, result interface{}, err error) {
+	sequence := 0
+	if len(params) == 0 {
+		sequence = recorder.sequence.next(method)
+	}
+	if writeErr := writeFixture(recorder.dir, method, params, sequence, result, err); writeErr != nil {
+		recorder.logger.Warn("unable to record node response", zap.String("method", method), zap.Error(writeErr))
+	}
+}
+
+// GetConfig retrieves the general application config that has been configured
+func (recorder *ZcoinClientRecorder) GetConfig() *configuration.Config {
+	return recorder.client.GetConfig()
+}
+
+// GetStatus will return the Blockchain base info based on that node
+func (recorder *ZcoinClientRecorder) GetStatus(ctx context.Context) (*btcjson.GetBlockChainInfoResult, error) {
+	result, err := recorder.client.GetStatus(ctx)
+	recorder.record("GetStatus", nil, result, err)
+	return result, err
+}
+
+// GetBlock will return you the block specification for a given height
+func (recorder *ZcoinClientRecorder) GetBlock(ctx context.Context, height int64) (*btcjson.GetBlockVerboseResult, error) {
+	result, err := recorder.client.GetBlock(ctx, height)
+	recorder.record("GetBlock", []interface{}{height}, result, err)
+	return result, err
+}
+
+// GetBlockByHash will return you the block specification for a given hash
+func (recorder *ZcoinClientRecorder) GetBlockByHash(ctx context.Context, hash string) (*btcjson.GetBlockVerboseResult, error) {
+	result, err := recorder.client.GetBlockByHash(ctx, hash)
+	recorder.record("GetBlockByHash", []interface{}{hash}, result, err)
+	return result, err
+}
+
+// GetLatestBlock returns the latest Zcoin block.
+func (recorder *ZcoinClientRecorder) GetLatestBlock(ctx context.Context) (*wire.MsgBlock, error) {
+	result, err := recorder.client.GetLatestBlock(ctx)
+	recorder.record("GetLatestBlock", nil, result, err)
+	return result, err
+}
+
+// GetBlockByHashWithTransaction returns the Zcoin block including transactions
+func (recorder *ZcoinClientRecorder) GetBlockByHashWithTransaction(ctx context.Context, hash string) (*GetBlockVerboseTxResult, error) {
+	result, err := recorder.client.GetBlockByHashWithTransaction(ctx, hash)
+	recorder.record("GetBlockByHashWithTransaction", []interface{}{hash}, result, err)
+	return result, err
+}
+
+// GetRawTransaction returns the transaction with the given hash
+func (recorder *ZcoinClientRecorder) GetRawTransaction(ctx context.Context, hash string) (*TxRawResult, error) {
+	result, err := recorder.client.GetRawTransaction(ctx, hash)
+	recorder.record("GetRawTransaction", []interface{}{hash}, result, err)
+	return result, err
+}
+
+// GetAddressBalance returns the balance of an address
+func (recorder *ZcoinClientRecorder) GetAddressBalance(ctx context.Context, address string) (*AddressBalanceResult, error) {
+	result, err := recorder.client.GetAddressBalance(ctx, address)
+	recorder.record("GetAddressBalance", []interface{}{address}, result, err)
+	return result, err
+}
+
+// GetRawMempool returns the hashes of the transactions currently in the mempool
+func (recorder *ZcoinClientRecorder) GetRawMempool(ctx context.Context) ([]string, error) {
+	result, err := recorder.client.GetRawMempool(ctx)
+	recorder.record("GetRawMempool", nil, result, err)
+	return result, err
+}
+
+// ListElysiumBlockTransactions returns the Elysium transactions of a block
+func (recorder *ZcoinClientRecorder) ListElysiumBlockTransactions(ctx context.Context, height int64) ([]string, error) {
+	result, err := recorder.client.ListElysiumBlockTransactions(ctx, height)
+	recorder.record("ListElysiumBlockTransactions", []interface{}{height}, result, err)
+	return result, err
+}
+
+// GetElysiumTransaction returns the Elysium payload of a transaction
+func (recorder *ZcoinClientRecorder) GetElysiumTransaction(ctx context.Context, hash string) (*ElysiumTransaction, error) {
+	result, err := recorder.client.GetElysiumTransaction(ctx, hash)
+	recorder.record("GetElysiumTransaction", []interface{}{hash}, result, err)
+	return result, err
+}
+
+// GetElysiumSTO returns the Elysium payload of a send to owners transaction
+func (recorder *ZcoinClientRecorder) GetElysiumSTO(ctx context.Context, hash string) (*ElysiumTransaction, error) {
+	result, err := recorder.client.GetElysiumSTO(ctx, hash)
+	recorder.record("GetElysiumSTO", []interface{}{hash}, result, err)
+	return result, err
+}
+
+// GetElysiumBalances returns the Elysium token balances of an address
+func (recorder *ZcoinClientRecorder) GetElysiumBalances(ctx context.Context, address string) ([]ElysiumBalance, error) {
+	result, err := recorder.client.GetElysiumBalances(ctx, address)
+	recorder.record("GetElysiumBalances", []interface{}{address}, result, err)
+	return result, err
+}
+
+// GetElysiumProperty returns an Elysium property
+func (recorder *ZcoinClientRecorder) GetElysiumProperty(ctx context.Context, propertyID int64) (*ElysiumProperty, error) {
+	result, err := recorder.client.GetElysiumProperty(ctx, propertyID)
+	recorder.record("GetElysiumProperty", []interface{}{propertyID}, result, err)
+	return result, err
+}
+
+// GetZnodeList returns the deterministic Znode list registered at given height
+func (recorder *ZcoinClientRecorder) GetZnodeList(ctx context.Context, height int64) (map[string]ZnodeInfo, error) {
+	result, err := recorder.client.GetZnodeList(ctx, height)
+	recorder.record("GetZnodeList", []interface{}{height}, result, err)
+	return result, err
+}
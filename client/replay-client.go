@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+// ZcoinClientReplay is an implementation of ZcoinClient answering from the
+// golden files written by ZcoinClientRecorder, it never contacts a node
+type ZcoinClientReplay struct {
+	dir               string
+	sequence          *fixtureSequence
+	applicationConfig *configuration.Config
+}
+
+// NewZcoinClientReplay returns a ZcoinClient replaying the fixtures in dir
+func NewZcoinClientReplay(applicationConfig *configuration.Config, dir string) ZcoinClient {
+	return &ZcoinClientReplay{
+		dir:               dir,
+		sequence:          newFixtureSequence(),
+		applicationConfig: applicationConfig,
+	}
+}
+
+// read loads the fixture of a call. Calls without parameters are replayed in
+// the order they were recorded and the last recording is repeated once they
+// are exhausted.
+func (replay *ZcoinClientReplay) read(method string, params []interface{}, result interface{}) error {
+	if len(params) > 0 {
+		return readFixture(replay.dir, method, params, 0, result)
+	}
+
+	sequence := replay.sequence.next(method)
+	err := readFixture(replay.dir, method, nil, sequence, result)
+	if errors.Cause(err) == ErrFixtureNotFound && sequence > 1 {
+		replay.sequence.rewind(method)
+		return readFixture(replay.dir, method, nil, sequence-1, result)
+	}
+
+	return err
+}
+
+// GetConfig retrieves the general application config that has been configured
+func (replay *ZcoinClientReplay) GetConfig() *configuration.Config {
+	return replay.applicationConfig
+}
+
+// GetStatus will return the Blockchain base info based on that node
+func (replay *ZcoinClientReplay) GetStatus(ctx context.Context) (*btcjson.GetBlockChainInfoResult, error) {
+	result := &btcjson.GetBlockChainInfoResult{}
+	if err := replay.read("GetStatus", nil, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetBlock will return you the block specification for a given height
+func (replay *ZcoinClientReplay) GetBlock(ctx context.Context, height int64) (*btcjson.GetBlockVerboseResult, error) {
+	result := &btcjson.GetBlockVerboseResult{}
+	if err := replay.read("GetBlock", []interface{}{height}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetBlockByHash will return you the block specification for a given hash
+func (replay *ZcoinClientReplay) GetBlockByHash(ctx context.Context, hash string) (*btcjson.GetBlockVerboseResult, error) {
+	result := &btcjson.GetBlockVerboseResult{}
+	if err := replay.read("GetBlockByHash", []interface{}{hash}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetLatestBlock returns the latest Zcoin block.
+func (replay *ZcoinClientReplay) GetLatestBlock(ctx context.Context) (*wire.MsgBlock, error) {
+	result := &wire.MsgBlock{}
+	if err := replay.read("GetLatestBlock", nil, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetBlockByHashWithTransaction returns the Zcoin block including transactions
+func (replay *ZcoinClientReplay) GetBlockByHashWithTransaction(ctx context.Context, hash string) (*GetBlockVerboseTxResult, error) {
+	result := &GetBlockVerboseTxResult{}
+	if err := replay.read("GetBlockByHashWithTransaction", []interface{}{hash}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetRawTransaction returns the transaction with the given hash
+func (replay *ZcoinClientReplay) GetRawTransaction(ctx context.Context, hash string) (*TxRawResult, error) {
+	result := &TxRawResult{}
+	if err := replay.read("GetRawTransaction", []interface{}{hash}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetAddressBalance returns the balance of an address
+func (replay *ZcoinClientReplay) GetAddressBalance(ctx context.Context, address string) (*AddressBalanceResult, error) {
+	result := &AddressBalanceResult{}
+	if err := replay.read("GetAddressBalance", []interface{}{address}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetRawMempool returns the hashes of the transactions currently in the mempool
+func (replay *ZcoinClientReplay) GetRawMempool(ctx context.Context) ([]string, error) {
+	var result []string
+	if err := replay.read("GetRawMempool", nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListElysiumBlockTransactions returns the Elysium transactions of a block
+func (replay *ZcoinClientReplay) ListElysiumBlockTransactions(ctx context.Context, height int64) ([]string, error) {
+	var result []string
+	if err := replay.read("ListElysiumBlockTransactions", []interface{}{height}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetElysiumTransaction returns the Elysium payload of a transaction
+func (replay *ZcoinClientReplay) GetElysiumTransaction(ctx context.Context, hash string) (*ElysiumTransaction, error) {
+	result := &ElysiumTransaction{}
+	if err := replay.read("GetElysiumTransaction", []interface{}{hash}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetElysiumSTO returns the Elysium payload of a send to owners transaction
+func (replay *ZcoinClientReplay) GetElysiumSTO(ctx context.Context, hash string) (*ElysiumTransaction, error) {
+	result := &ElysiumTransaction{}
+	if err := replay.read("GetElysiumSTO", []interface{}{hash}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetElysiumBalances returns the Elysium token balances of an address
+func (replay *ZcoinClientReplay) GetElysiumBalances(ctx context.Context, address string) ([]ElysiumBalance, error) {
+	var result []ElysiumBalance
+	if err := replay.read("GetElysiumBalances", []interface{}{address}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetElysiumProperty returns an Elysium property
+func (replay *ZcoinClientReplay) GetElysiumProperty(ctx context.Context, propertyID int64) (*ElysiumProperty, error) {
+	result := &ElysiumProperty{}
+	if err := replay.read("GetElysiumProperty", []interface{}{propertyID}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetZnodeList returns the deterministic Znode list registered at given height
+func (replay *ZcoinClientReplay) GetZnodeList(ctx context.Context, height int64) (map[string]ZnodeInfo, error) {
+	result := make(map[string]ZnodeInfo)
+	if err := replay.read("GetZnodeList", []interface{}{height}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
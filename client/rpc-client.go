@@ -2,22 +2,64 @@ package client
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"fmt"
 
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btcd/wire"
+	"go.uber.org/zap"
 	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
 )
 
 const (
-	Transfer      = "transfer"
-	StatusSuccess = "success"
-	StatusFail    = "fail"
-	ActionTypeFee = "fee"
+	Transfer          = "transfer"
+	SigmaMint         = "sigma_mint"
+	SigmaSpend        = "sigma_spend"
+	LelantusMint      = "lelantus_mint"
+	LelantusJoinSplit = "lelantus_joinsplit"
+	NullData          = "null_data"
+	ElysiumSend       = "elysium_send"
+	ElysiumIssuance   = "elysium_issuance"
+	ElysiumRevoke     = "elysium_revoke"
+	ElysiumFee        = "elysium_fee"
+	StatusSuccess     = "success"
+	StatusFail        = "fail"
+	ActionTypeFee     = "fee"
 )
 
+// OperationTypes are all the operation types the gateway can return
+var OperationTypes = []string{
+	Transfer,
+	SigmaMint,
+	SigmaSpend,
+	LelantusMint,
+	LelantusJoinSplit,
+	NullData,
+	ElysiumSend,
+	ElysiumIssuance,
+	ElysiumRevoke,
+	ElysiumFee,
+	ActionTypeFee,
+}
+
+// Pseudo-accounts holding the value shielded in each privacy pool, they are
+// not valid addresses so they never collide with a real account
+const (
+	SIGMA_POOL_ADDRESS    = "sigma_pool"
+	LELANTUS_POOL_ADDRESS = "lelantus_pool"
+)
+
+// FEE_POOL_ADDRESS is the pseudo-account transaction fees are credited to
+// until the coinbase of their block claims them
+const FEE_POOL_ADDRESS = "fee_pool"
+
+// IsPoolAddress returns true for the privacy pool pseudo-accounts
+func IsPoolAddress(address string) bool {
+	return address == SIGMA_POOL_ADDRESS || address == LELANTUS_POOL_ADDRESS
+}
+
 const (
 	WITNESS_V0 = "witness_v0_keyhash"
 	P2PKH      = "pubkeyhash"
@@ -27,19 +69,17 @@ const (
 const BASE_CURRENCY_DECIMAL_DIVIDER = 100000000
 const BASE_CURRENCY_DECIMAL_COUNT = 8
 const CURRENCY_SYMBOL = "XZC"
-
-func IsValidPaymentType(paymentType string) bool {
-	return paymentType == P2PKH || paymentType == WITNESS_V0 || paymentType == PUBKEY
-}
+const ELYSIUM_SYMBOL_PREFIX = "ELYSIUM-"
 
 // ZcoinClientRPC is an implementation of ZcoinClient using RPC.
 type ZcoinClientRPC struct {
 	rpcConnConfig     *rpcclient.ConnConfig
 	applicationConfig *configuration.Config
+	logger            *zap.Logger
 }
 
 // NewZcoinClient returns an implementation of ZcoinClient
-func NewZcoinClient(applicationConfig *configuration.Config) (cli ZcoinClient) {
+func NewZcoinClient(applicationConfig *configuration.Config, logger *zap.Logger) (cli ZcoinClient) {
 	rpcConnConfig := rpcclient.ConnConfig{
 		Host:         applicationConfig.Node.Endpoint,
 		User:         applicationConfig.Node.Username,
@@ -48,10 +88,16 @@ func NewZcoinClient(applicationConfig *configuration.Config) (cli ZcoinClient) {
 		DisableTLS:   !applicationConfig.Node.TLSEnabled, // Bitcoin core does not provide TLS by default
 	}
 
-	return &ZcoinClientRPC{
+	cli = NewZcoinClientMetrics(&ZcoinClientRPC{
 		rpcConnConfig:     &rpcConnConfig,
 		applicationConfig: applicationConfig,
+		logger:            logger,
+	}, logger)
+	if applicationConfig.Node.RecordPath != "" {
+		cli = NewZcoinClientRecorder(cli, applicationConfig.Node.RecordPath, logger)
 	}
+
+	return cli
 }
 
 func (rpcClient *ZcoinClientRPC) reconnect() (client *rpcclient.Client) {
@@ -59,7 +105,7 @@ func (rpcClient *ZcoinClientRPC) reconnect() (client *rpcclient.Client) {
 	// not supported in HTTP POST mode.
 	client, err := rpcclient.New(rpcClient.rpcConnConfig, nil)
 	if err != nil {
-		log.Fatal(err)
+		rpcClient.logger.Fatal("unable to create RPC client", zap.Error(err))
 	}
 
 	return client
@@ -85,6 +131,10 @@ func (rpcClient *ZcoinClientRPC) GetBlock(ctx context.Context, height int64) (*b
 	defer client.Shutdown()
 
 	result, err := client.GetBlockHash(height)
+	if err != nil {
+		return nil, err
+	}
+
 	block, err := client.GetBlockVerbose(result)
 	return block, err
 }
@@ -126,19 +176,174 @@ func (rpcClient *ZcoinClientRPC) GetLatestBlock(ctx context.Context) (*wire.MsgB
 }
 
 // GetBlockByHashWithTransaction returns the Zcoin block including transactions
-func (rpcClient *ZcoinClientRPC) GetBlockByHashWithTransaction(ctx context.Context, hash string) (*btcjson.GetBlockVerboseTxResult, error) {
+func (rpcClient *ZcoinClientRPC) GetBlockByHashWithTransaction(ctx context.Context, hash string) (*GetBlockVerboseTxResult, error) {
 	client := rpcClient.reconnect()
 	defer client.Shutdown()
 
-	blockHash, err := chainhash.NewHashFromStr(hash)
+	block := &GetBlockVerboseTxResult{}
+	if err := rawRequest(client, block, "getblock", hash, 2); err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+// GetRawMempool returns the hashes of the transactions currently in the mempool
+func (rpcClient *ZcoinClientRPC) GetRawMempool(ctx context.Context) ([]string, error) {
+	client := rpcClient.reconnect()
+	defer client.Shutdown()
+
+	hashes, err := client.GetRawMempool()
 	if err != nil {
 		return nil, err
 	}
 
-	block, err := client.GetBlockVerboseTx(blockHash)
+	txs := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		txs = append(txs, hash.String())
+	}
+
+	return txs, nil
+}
+
+// GetRawTransaction returns the verbose transaction, the node needs -txindex
+// to find transactions outside of the mempool
+func (rpcClient *ZcoinClientRPC) GetRawTransaction(ctx context.Context, hash string) (*TxRawResult, error) {
+	client := rpcClient.reconnect()
+	defer client.Shutdown()
+
+	tx := &TxRawResult{}
+	if err := rawRequest(client, tx, "getrawtransaction", hash, 1); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// GetAddressBalance returns the balance of an address, the node needs -addressindex
+func (rpcClient *ZcoinClientRPC) GetAddressBalance(ctx context.Context, address string) (*AddressBalanceResult, error) {
+	client := rpcClient.reconnect()
+	defer client.Shutdown()
+
+	result := &AddressBalanceResult{}
+	params := map[string][]string{
+		"addresses": {address},
+	}
+	if err := rawRequest(client, result, "getaddressbalance", params); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ListElysiumBlockTransactions returns the hashes of the Elysium transactions
+// mined in the block at height
+func (rpcClient *ZcoinClientRPC) ListElysiumBlockTransactions(ctx context.Context, height int64) ([]string, error) {
+	client := rpcClient.reconnect()
+	defer client.Shutdown()
+
+	var txs []string
+	if err := rawRequest(client, &txs, "elysium_listblocktransactions", height); err != nil {
+		return nil, err
+	}
+
+	return txs, nil
+}
+
+// GetElysiumTransaction returns the decoded Elysium payload of a transaction
+func (rpcClient *ZcoinClientRPC) GetElysiumTransaction(ctx context.Context, hash string) (*ElysiumTransaction, error) {
+	client := rpcClient.reconnect()
+	defer client.Shutdown()
+
+	tx := &ElysiumTransaction{}
+	if err := rawRequest(client, tx, "elysium_gettransaction", hash); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// GetElysiumSTO returns the decoded Elysium payload of a send to owners
+// transaction along with every recipient
+func (rpcClient *ZcoinClientRPC) GetElysiumSTO(ctx context.Context, hash string) (*ElysiumTransaction, error) {
+	client := rpcClient.reconnect()
+	defer client.Shutdown()
+
+	tx := &ElysiumTransaction{}
+	if err := rawRequest(client, tx, "elysium_getsto", hash, "*"); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// GetElysiumBalances returns every Elysium token balance of an address, an
+// address that never held a token has no balances
+func (rpcClient *ZcoinClientRPC) GetElysiumBalances(ctx context.Context, address string) ([]ElysiumBalance, error) {
+	client := rpcClient.reconnect()
+	defer client.Shutdown()
+
+	var balances []ElysiumBalance
+	err := rawRequest(client, &balances, "elysium_getallbalancesforaddress", address)
+	if rpcErr, ok := err.(*btcjson.RPCError); ok && rpcErr.Code == btcjson.ErrRPCInvalidParameter {
+		return []ElysiumBalance{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return block, nil
+	return balances, nil
+}
+
+// GetElysiumProperty returns an Elysium property
+func (rpcClient *ZcoinClientRPC) GetElysiumProperty(ctx context.Context, propertyID int64) (*ElysiumProperty, error) {
+	client := rpcClient.reconnect()
+	defer client.Shutdown()
+
+	property := &ElysiumProperty{}
+	if err := rawRequest(client, property, "elysium_getproperty", propertyID); err != nil {
+		return nil, err
+	}
+
+	return property, nil
+}
+
+// GetZnodeList returns the deterministic Znode list registered at given height
+// keyed by collateral outpoint
+func (rpcClient *ZcoinClientRPC) GetZnodeList(ctx context.Context, height int64) (map[string]ZnodeInfo, error) {
+	client := rpcClient.reconnect()
+	defer client.Shutdown()
+
+	var list []ProTxInfo
+	if err := rawRequest(client, &list, "protx", "list", "registered", true, height); err != nil {
+		return nil, err
+	}
+
+	znodes := make(map[string]ZnodeInfo, len(list))
+	for i := range list {
+		outpoint := fmt.Sprintf("%s-%d", list[i].CollateralHash, list[i].CollateralIndex)
+		znodes[outpoint] = list[i].Znode()
+	}
+
+	return znodes, nil
+}
+
+// rawRequest calls an RPC method rpcclient has no typed command for, or whose
+// result carries zcoind specific fields, and decodes the result
+func rawRequest(client *rpcclient.Client, result interface{}, method string, params ...interface{}) error {
+	rawParams := make([]json.RawMessage, 0, len(params))
+	for _, param := range params {
+		raw, err := json.Marshal(param)
+		if err != nil {
+			return err
+		}
+		rawParams = append(rawParams, raw)
+	}
+
+	raw, err := client.RawRequest(method, rawParams)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, result)
 }
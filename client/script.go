@@ -0,0 +1,72 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+const (
+	SCRIPT_HASH           = "scripthash"
+	WITNESS_V0_SCRIPTHASH = "witness_v0_scripthash"
+	MULTISIG              = "multisig"
+	NULL_DATA             = "nulldata"
+	NONSTANDARD           = "nonstandard"
+)
+
+// Pseudo-accounts for outputs without a single owning address, OP_RETURN
+// outputs share one and any other script gets one per script hash
+const (
+	NULL_DATA_ADDRESS     = "null_data"
+	SCRIPT_ADDRESS_PREFIX = "script_"
+)
+
+// IsPseudoAddress returns true for accounts that are not zcoin addresses and
+// therefore have no balance on the node
+func IsPseudoAddress(address string) bool {
+	return IsPoolAddress(address) || address == FEE_POOL_ADDRESS || address == NULL_DATA_ADDRESS ||
+		strings.HasPrefix(address, SCRIPT_ADDRESS_PREFIX)
+}
+
+// IsNullData returns true for OP_RETURN outputs
+func IsNullData(script *btcjson.ScriptPubKeyResult) bool {
+	return script.Type == NULL_DATA
+}
+
+// ScriptAddress returns the account owning the value locked by a script. Scripts
+// resolving to a single address belong to it, multisig and nonstandard scripts
+// belong to a pseudo-account derived from the script so spends debit it back.
+func ScriptAddress(script *btcjson.ScriptPubKeyResult) string {
+	if IsNullData(script) {
+		return NULL_DATA_ADDRESS
+	}
+	if len(script.Addresses) == 1 {
+		return script.Addresses[0]
+	}
+
+	raw, err := hex.DecodeString(script.Hex)
+	if err != nil {
+		raw = []byte(script.Hex)
+	}
+	hash := sha256.Sum256(raw)
+	return SCRIPT_ADDRESS_PREFIX + hex.EncodeToString(hash[:])
+}
+
+// NullDataPayload returns the data pushed by an OP_RETURN script
+func NullDataPayload(script *btcjson.ScriptPubKeyResult) (string, error) {
+	raw, err := hex.DecodeString(script.Hex)
+	if err != nil {
+		return "", err
+	}
+
+	pushes, err := txscript.PushedData(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes.Join(pushes, nil)), nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+func TestScriptAddress(t *testing.T) {
+	multisig := &btcjson.ScriptPubKeyResult{
+		Hex:       "5121020202020202020202020202020202020202020202020202020202020202020221030303030303030303030303030303030303030303030303030303030303030352ae",
+		Type:      MULTISIG,
+		Addresses: []string{"TAmVDhmNuC4Qsb3xkwYmzGpBkbtaQUGusx", "TBjJvmhWAT1uLDuA5FU6DvGWpBjt8TBXsu"},
+	}
+
+	tests := []struct {
+		name   string
+		script *btcjson.ScriptPubKeyResult
+		want   string
+	}{
+		{
+			name:   "single address",
+			script: &btcjson.ScriptPubKeyResult{Type: P2PKH, Addresses: []string{"TAmVDhmNuC4Qsb3xkwYmzGpBkbtaQUGusx"}},
+			want:   "TAmVDhmNuC4Qsb3xkwYmzGpBkbtaQUGusx",
+		},
+		{
+			name:   "null data",
+			script: &btcjson.ScriptPubKeyResult{Hex: "6a057a636f696e", Type: NULL_DATA},
+			want:   NULL_DATA_ADDRESS,
+		},
+		{
+			name:   "multisig",
+			script: multisig,
+			want:   "script_c546eb43394fd22ae46a199cfa19a87c61a0c29e419b198e10143394531929f9",
+		},
+		{
+			name:   "nonstandard",
+			script: &btcjson.ScriptPubKeyResult{Hex: "51", Type: NONSTANDARD},
+			want:   "script_4ae81572f06e1b88fd5ced7a1a000945432e83e1551e6f721ee9c00b8cc33260",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ScriptAddress(test.script)
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+			if pseudo := strings.HasPrefix(test.want, SCRIPT_ADDRESS_PREFIX) || test.want == NULL_DATA_ADDRESS; IsPseudoAddress(got) != pseudo {
+				t.Errorf("got pseudo-account %t, want %t", IsPseudoAddress(got), pseudo)
+			}
+		})
+	}
+}
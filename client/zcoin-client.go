@@ -8,6 +8,13 @@ import (
 	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
 )
 
+// AddressBalanceResult models the data from the getaddressbalance command,
+// values are in satoshis
+type AddressBalanceResult struct {
+	Balance  int64 `json:"balance"`
+	Received int64 `json:"received"`
+}
+
 // ZcoinClient is the Zcoin blockchain client interface
 type ZcoinClient interface {
 	// GetBlock returns the Zcoin block at given height.
@@ -17,11 +24,38 @@ type ZcoinClient interface {
 	GetBlockByHash(ctx context.Context, hash string) (*btcjson.GetBlockVerboseResult, error)
 
 	// GetBlock returns the Zcoin block with a given hash.
-	GetBlockByHashWithTransaction(ctx context.Context, hash string) (*btcjson.GetBlockVerboseTxResult, error)
+	GetBlockByHashWithTransaction(ctx context.Context, hash string) (*GetBlockVerboseTxResult, error)
 
 	// GetLatestBlock returns the latest Zcoin block.
 	GetLatestBlock(ctx context.Context) (*wire.MsgBlock, error)
 
+	// GetRawTransaction returns the transaction with the given hash.
+	GetRawTransaction(ctx context.Context, hash string) (*TxRawResult, error)
+
+	// GetAddressBalance returns the confirmed balance of an address in satoshis.
+	GetAddressBalance(ctx context.Context, address string) (*AddressBalanceResult, error)
+
+	// GetRawMempool returns the hashes of the transactions in the node's mempool.
+	GetRawMempool(ctx context.Context) ([]string, error)
+
+	// ListElysiumBlockTransactions returns the Elysium transactions of the block at given height.
+	ListElysiumBlockTransactions(ctx context.Context, height int64) ([]string, error)
+
+	// GetElysiumTransaction returns the Elysium payload of the transaction with the given hash.
+	GetElysiumTransaction(ctx context.Context, hash string) (*ElysiumTransaction, error)
+
+	// GetElysiumSTO returns the Elysium payload of a send to owners transaction with its recipients.
+	GetElysiumSTO(ctx context.Context, hash string) (*ElysiumTransaction, error)
+
+	// GetElysiumBalances returns the Elysium token balances of an address.
+	GetElysiumBalances(ctx context.Context, address string) ([]ElysiumBalance, error)
+
+	// GetElysiumProperty returns the Elysium property with the given ID.
+	GetElysiumProperty(ctx context.Context, propertyID int64) (*ElysiumProperty, error)
+
+	// GetZnodeList returns the deterministic Znodes registered at given height keyed by collateral outpoint.
+	GetZnodeList(ctx context.Context, height int64) (map[string]ZnodeInfo, error)
+
 	// GetStatus returns the status overview of the node.
 	GetStatus(ctx context.Context) (*btcjson.GetBlockChainInfoResult, error)
 
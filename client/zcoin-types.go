@@ -0,0 +1,143 @@
+package client
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// Opcodes zcoind prefixes privacy scripts with
+const (
+	OP_SIGMAMINT         = 0xc3
+	OP_SIGMASPEND        = 0xc4
+	OP_LELANTUSMINT      = 0xc5
+	OP_LELANTUSJMINT     = 0xc6
+	OP_LELANTUSJOINSPLIT = 0xc7
+)
+
+// ScriptPubKey types reported by zcoind for privacy mints
+const (
+	SIGMA_MINT    = "zerocoinmintv3"
+	LELANTUS_MINT = "lelantusmint"
+)
+
+// Vin models a zcoind transaction input. Privacy spends reference no previous
+// output, sigma spends report their denomination and joinsplits their fee.
+type Vin struct {
+	Coinbase       string             `json:"coinbase,omitempty"`
+	Txid           string             `json:"txid,omitempty"`
+	Vout           uint32             `json:"vout"`
+	ScriptSig      *btcjson.ScriptSig `json:"scriptSig,omitempty"`
+	Sequence       uint32             `json:"sequence"`
+	AnonymityGroup *int64             `json:"anonymityGroup,omitempty"`
+	Value          float64            `json:"value,omitempty"`
+	NFees          *float64           `json:"nFees,omitempty"`
+}
+
+// IsCoinBase returns true for the input of a coinbase transaction
+func (v *Vin) IsCoinBase() bool {
+	return len(v.Coinbase) > 0
+}
+
+// IsSigmaSpend returns true for an input spending a sigma mint
+func (v *Vin) IsSigmaSpend() bool {
+	return v.hasOpcode(OP_SIGMASPEND) || (v.AnonymityGroup != nil && v.NFees == nil)
+}
+
+// IsLelantusJoinSplit returns true for a lelantus joinsplit input
+func (v *Vin) IsLelantusJoinSplit() bool {
+	return v.hasOpcode(OP_LELANTUSJOINSPLIT) || v.NFees != nil
+}
+
+// IsPrivacySpend returns true for inputs taking value out of a privacy pool
+func (v *Vin) IsPrivacySpend() bool {
+	return v.IsSigmaSpend() || v.IsLelantusJoinSplit()
+}
+
+func (v *Vin) hasOpcode(opcode byte) bool {
+	return v.ScriptSig != nil && hasOpcode(v.ScriptSig.Hex, opcode)
+}
+
+func hasOpcode(script string, opcode byte) bool {
+	return strings.HasPrefix(strings.ToLower(script), hex.EncodeToString([]byte{opcode}))
+}
+
+// MintType returns the operation type of an output minting into a privacy
+// pool, or an empty string for any other output. Joinsplit mints hide their
+// value so they never carry an operation.
+func MintType(vout *btcjson.Vout) string {
+	script := vout.ScriptPubKey
+	switch {
+	case script.Type == SIGMA_MINT || hasOpcode(script.Hex, OP_SIGMAMINT):
+		return SigmaMint
+	case script.Type == LELANTUS_MINT || hasOpcode(script.Hex, OP_LELANTUSMINT):
+		return LelantusMint
+	}
+
+	return ""
+}
+
+// TxRawResult models a zcoind verbose transaction
+type TxRawResult struct {
+	Hex           string         `json:"hex"`
+	Txid          string         `json:"txid"`
+	Hash          string         `json:"hash,omitempty"`
+	Size          int32          `json:"size,omitempty"`
+	Vsize         int32          `json:"vsize,omitempty"`
+	Weight        int32          `json:"weight,omitempty"`
+	Version       int32          `json:"version"`
+	LockTime      uint32         `json:"locktime"`
+	Vin           []Vin          `json:"vin"`
+	Vout          []btcjson.Vout `json:"vout"`
+	BlockHash     string         `json:"blockhash,omitempty"`
+	Confirmations int64          `json:"confirmations,omitempty"`
+	Time          int64          `json:"time,omitempty"`
+	Blocktime     int64          `json:"blocktime,omitempty"`
+
+	Type             int32  `json:"type,omitempty"`
+	ExtraPayloadSize int    `json:"extraPayloadSize,omitempty"`
+	ExtraPayload     string `json:"extraPayload,omitempty"`
+}
+
+// SpecialTxType returns the evo special transaction type, zcoind reports it
+// separately but it is also packed in the upper 16 bits of the raw version
+func (tx *TxRawResult) SpecialTxType() int32 {
+	if tx.Type != TRANSACTION_NORMAL {
+		return tx.Type
+	}
+
+	return tx.Version >> 16
+}
+
+// GetBlockVerboseTxResult models a zcoind block returned with verbosity 2
+type GetBlockVerboseTxResult struct {
+	Hash          string        `json:"hash"`
+	Confirmations int64         `json:"confirmations"`
+	StrippedSize  int32         `json:"strippedsize"`
+	Size          int32         `json:"size"`
+	Weight        int32         `json:"weight"`
+	Height        int64         `json:"height"`
+	Version       int32         `json:"version"`
+	VersionHex    string        `json:"versionHex"`
+	MerkleRoot    string        `json:"merkleroot"`
+	Tx            []TxRawResult `json:"tx,omitempty"`
+	Time          int64         `json:"time"`
+	Nonce         uint32        `json:"nonce"`
+	Bits          string        `json:"bits"`
+	Difficulty    float64       `json:"difficulty"`
+	PreviousHash  string        `json:"previousblockhash"`
+	NextHash      string        `json:"nextblockhash,omitempty"`
+	MedianTime    int64         `json:"mediantime,omitempty"`
+	ChainWork     string        `json:"chainwork,omitempty"`
+
+	// MTP header fields, reported for blocks mined after the MTP switch
+	MTPVersion   string `json:"mtpVersion,omitempty"`
+	MTPHashValue string `json:"mtpHashValue,omitempty"`
+	MTPReserved0 string `json:"mtpReserved[0],omitempty"`
+	MTPReserved1 string `json:"mtpReserved[1],omitempty"`
+
+	// ProgPoW header fields, reported for blocks mined after the ProgPoW switch
+	Nonce64 uint64 `json:"nonce64,omitempty"`
+	MixHash string `json:"mix_hash,omitempty"`
+}
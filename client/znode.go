@@ -0,0 +1,64 @@
+package client
+
+const (
+	// ZnodeEnabled is the status of a Znode eligible for payouts
+	ZnodeEnabled = "ENABLED"
+	// ZnodePoSeBanned is the status of a Znode banned by the proof of service
+	ZnodePoSeBanned = "POSE_BANNED"
+)
+
+// ZnodeInfo describes a deterministic Znode, the list is keyed by the
+// collateral outpoint formatted as txid-index
+type ZnodeInfo struct {
+	ProTxHash         string `json:"proTxHash"`
+	Address           string `json:"address"`
+	Payee             string `json:"payee"`
+	Status            string `json:"status"`
+	LastPaidTime      int64  `json:"lastpaidtime"`
+	LastPaidBlock     int64  `json:"lastpaidblock"`
+	OwnerAddress      string `json:"owneraddress"`
+	VotingAddress     string `json:"votingaddress"`
+	CollateralAddress string `json:"collateraladdress"`
+	PubKeyOperator    string `json:"pubkeyoperator"`
+}
+
+// ProTxState is the state of a Znode at the height it was listed at
+type ProTxState struct {
+	Service          string `json:"service"`
+	RegisteredHeight int64  `json:"registeredHeight"`
+	LastPaidHeight   int64  `json:"lastPaidHeight"`
+	PoSeBanHeight    int64  `json:"PoSeBanHeight"`
+	OwnerAddress     string `json:"ownerAddress"`
+	VotingAddress    string `json:"votingAddress"`
+	PayoutAddress    string `json:"payoutAddress"`
+	PubKeyOperator   string `json:"pubKeyOperator"`
+}
+
+// ProTxInfo models an entry of the protx list registered true command
+type ProTxInfo struct {
+	ProTxHash         string     `json:"proTxHash"`
+	CollateralHash    string     `json:"collateralHash"`
+	CollateralIndex   uint32     `json:"collateralIndex"`
+	CollateralAddress string     `json:"collateralAddress"`
+	State             ProTxState `json:"state"`
+}
+
+// Znode converts the protx entry to the Znode it describes
+func (info *ProTxInfo) Znode() ZnodeInfo {
+	status := ZnodeEnabled
+	if info.State.PoSeBanHeight > 0 {
+		status = ZnodePoSeBanned
+	}
+
+	return ZnodeInfo{
+		ProTxHash:         info.ProTxHash,
+		Address:           info.State.Service,
+		Payee:             info.State.PayoutAddress,
+		Status:            status,
+		LastPaidBlock:     info.State.LastPaidHeight,
+		OwnerAddress:      info.State.OwnerAddress,
+		VotingAddress:     info.State.VotingAddress,
+		CollateralAddress: info.CollateralAddress,
+		PubKeyOperator:    info.State.PubKeyOperator,
+	}
+}
@@ -7,6 +7,8 @@
 package configuration
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 	uconfig "go.uber.org/config"
 )
@@ -27,24 +29,91 @@ type (
 		Symbol   string `yaml:"symbol"`
 		Decimals int32  `yaml:"decimals"`
 	}
-	// Server represents setting for this rosetta data server
+	// Server represents setting for this rosetta data server, TLS is served
+	// when both TLSCertFile and TLSKeyFile are set and an empty CORSOrigins
+	// allows no cross-origin request
 	Server struct {
-		Port string `yaml:"port"`
+		Address        string        `yaml:"address"`
+		Port           string        `yaml:"port"`
+		ReadTimeout    time.Duration `yaml:"readTimeout"`
+		WriteTimeout   time.Duration `yaml:"writeTimeout"`
+		IdleTimeout    time.Duration `yaml:"idleTimeout"`
+		TLSCertFile    string        `yaml:"tlsCertFile"`
+		TLSKeyFile     string        `yaml:"tlsKeyFile"`
+		CORSOrigins    []string      `yaml:"corsOrigins"`
+		MaxRequestSize int64         `yaml:"maxRequestSize"`
 	}
 
-	// Node specifies the connection details towards a given node
+	// Node specifies the connection details towards a given node,
+	// RecordPath saves every node response as golden files when set
 	Node struct {
 		Endpoint   string `yaml:"endpoint"`
 		TLSEnabled bool   `yaml:"tlsEnabled"`
 		Username   string `yaml:"username"`
 		Password   string `yaml:"password"`
+		RecordPath string `yaml:"recordPath"`
+	}
+
+	// ZMQ specifies the zcoind ZMQ publisher endpoints (-zmqpubhashblock and
+	// -zmqpubrawtx), leaving them empty falls back to polling the node
+	ZMQ struct {
+		HashBlock string `yaml:"hashBlock"`
+		RawTx     string `yaml:"rawTx"`
+	}
+
+	// Indexer specifies where and how often the chain is indexed
+	Indexer struct {
+		DatabasePath string        `yaml:"databasePath"`
+		PollInterval time.Duration `yaml:"pollInterval"`
+	}
+
+	// Cache bounds the in-memory cache of mapped blocks, blocks with at least
+	// ConfirmationDepth blocks on top of them are considered final
+	Cache struct {
+		Size              int   `yaml:"size"`
+		ConfirmationDepth int64 `yaml:"confirmationDepth"`
+	}
+
+	// Subscriptions tunes the WebSocket subscriptions, a client letting more
+	// than BufferSize notifications pile up is disconnected
+	Subscriptions struct {
+		BufferSize int `yaml:"bufferSize"`
+	}
+
+	// Webhooks tunes the delivery of the deposit webhooks, a delivery is
+	// given up after MaxAttempts failed calls. Webhook URLs are restricted to
+	// AllowedHosts when set, and may only reach loopback, link-local and
+	// private addresses when AllowPrivateNetworks is set
+	Webhooks struct {
+		MaxAttempts          int           `yaml:"maxAttempts"`
+		Timeout              time.Duration `yaml:"timeout"`
+		AllowedHosts         []string      `yaml:"allowedHosts"`
+		AllowPrivateNetworks bool          `yaml:"allowPrivateNetworks"`
+	}
+
+	// Elysium enables the Elysium token layer, the node has to run with -elysium
+	Elysium struct {
+		Enabled bool `yaml:"enabled"`
+	}
+
+	// Health tunes the readiness probe, the gateway is not ready while the
+	// indexer is more than MaxIndexerLag blocks behind the node
+	Health struct {
+		MaxIndexerLag int64 `yaml:"maxIndexerLag"`
+	}
+
+	// Logging sets the minimum level logged (debug, info, warn or error) and
+	// the output format, json or console
+	Logging struct {
+		Level  string `yaml:"level"`
+		Format string `yaml:"format"`
 	}
 
 	// Version is representing the version of the specification
 	// for both rosetta and the given node
 	Version struct {
-		RosettaVersion  string `yaml:"rosettaVersion"`
-		ZcoinVersion string `yaml:"ZcoinVersion"`
+		RosettaVersion string `yaml:"rosettaVersion"`
+		ZcoinVersion   string `yaml:"ZcoinVersion"`
 	}
 
 	// Config is the overall configuration for this service layer
@@ -53,6 +122,14 @@ type (
 		Currency          Currency          `yaml:"currency"`
 		Server            Server            `yaml:"server"`
 		Node              Node              `yaml:"node"`
+		ZMQ               ZMQ               `yaml:"zmq"`
+		Indexer           Indexer           `yaml:"indexer"`
+		Cache             Cache             `yaml:"cache"`
+		Subscriptions     Subscriptions     `yaml:"subscriptions"`
+		Webhooks          Webhooks          `yaml:"webhooks"`
+		Elysium           Elysium           `yaml:"elysium"`
+		Health            Health            `yaml:"health"`
+		Logging           Logging           `yaml:"logging"`
 		Version           Version           `yaml:"version"`
 	}
 )
@@ -70,3 +147,8 @@ func New(path string) (cfg *Config, err error) {
 	}
 	return
 }
+
+// Enabled reports whether at least one ZMQ topic has been configured
+func (z ZMQ) Enabled() bool {
+	return z.HashBlock != "" || z.RawTx != ""
+}
@@ -3,12 +3,17 @@ module github.com/ArcadiaMediaGroup/zcoin-rosetta-node
 go 1.12
 
 require (
-        github.com/btcsuite/btcd v0.20.1-beta
+        github.com/btcsuite/btcd v0.20.1-beta.0.20200629141510-e2d9cf4b5508
+        github.com/btcsuite/btcutil v1.0.2
         github.com/coinbase/rosetta-sdk-go v0.3.2
         github.com/dgraph-io/badger v1.6.1
+        github.com/go-zeromq/zmq4 v0.10.0
         github.com/google/wire v0.4.0
+        github.com/gorilla/websocket v1.4.2
         github.com/pkg/errors v0.9.1
+        github.com/prometheus/client_golang v1.7.1
         go.uber.org/config v1.4.0
+        go.uber.org/zap v1.15.0
 )
 
 
@@ -0,0 +1,176 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+)
+
+// DefaultMaxIndexerLag is used when no maximum indexer lag has been configured
+const DefaultMaxIndexerLag = 6
+
+// Component statuses
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Component is the status of one of the parts the gateway depends on
+type Component struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Height *int64 `json:"height,omitempty"`
+}
+
+// Response reports the overall status along with the status of every component
+type Response struct {
+	Status     string                `json:"status"`
+	Components map[string]*Component `json:"components,omitempty"`
+}
+
+// Controller serves the liveness and readiness probes, they are not part of
+// the Rosetta specification and take no network identifier
+type Controller struct {
+	client        client.ZcoinClient
+	blocks        *repository.BlockProvider
+	genesis       *types.BlockIdentifier
+	maxIndexerLag int64
+}
+
+// NewController creates the health controller for the genesis block verified
+// at startup, the gateway is ready while the indexer is at most
+// cfg.MaxIndexerLag blocks behind the node
+func NewController(
+	client client.ZcoinClient,
+	blocks *repository.BlockProvider,
+	genesis *types.BlockIdentifier,
+	cfg configuration.Health,
+) server.Router {
+	maxIndexerLag := cfg.MaxIndexerLag
+	if maxIndexerLag <= 0 {
+		maxIndexerLag = DefaultMaxIndexerLag
+	}
+
+	return &Controller{
+		client:        client,
+		blocks:        blocks,
+		genesis:       genesis,
+		maxIndexerLag: maxIndexerLag,
+	}
+}
+
+// Routes returns all of the api routes for the Controller
+func (c *Controller) Routes() server.Routes {
+	return server.Routes{
+		{
+			Name:        "Healthz",
+			Method:      http.MethodGet,
+			Pattern:     "/healthz",
+			HandlerFunc: c.Healthz,
+		},
+		{
+			Name:        "Readyz",
+			Method:      http.MethodGet,
+			Pattern:     "/readyz",
+			HandlerFunc: c.Readyz,
+		},
+	}
+}
+
+// Healthz - Report that the process is alive
+func (c *Controller) Healthz(w http.ResponseWriter, r *http.Request) {
+	server.EncodeJSONResponse(&Response{Status: StatusOK}, http.StatusOK, w)
+}
+
+// Readyz - Report whether the node is reachable and on the configured chain
+// and whether the indexer keeps up with it, the node is called once per probe
+func (c *Controller) Readyz(w http.ResponseWriter, r *http.Request) {
+	nodeStatus, err := c.client.GetStatus(r.Context())
+	node, nodeHeight := c.node(nodeStatus, err)
+	response := &Response{
+		Status: StatusOK,
+		Components: map[string]*Component{
+			"node":    node,
+			"chain":   c.chain(nodeStatus, err),
+			"indexer": c.indexer(nodeHeight),
+		},
+	}
+
+	status := http.StatusOK
+	for _, component := range response.Components {
+		if component.Status != StatusOK {
+			response.Status = StatusError
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	server.EncodeJSONResponse(response, status, w)
+}
+
+func failed(err error) *Component {
+	return &Component{
+		Status: StatusError,
+		Error:  err.Error(),
+	}
+}
+
+// node returns the status of the node and its height, nil when unreachable
+func (c *Controller) node(status *btcjson.GetBlockChainInfoResult, err error) (*Component, *int64) {
+	if err != nil {
+		return failed(err), nil
+	}
+
+	height := int64(status.Blocks)
+	return &Component{
+		Status: StatusOK,
+		Height: &height,
+	}, &height
+}
+
+// chain checks the chain the node reports against the configured network,
+// the genesis block itself was verified at startup
+func (c *Controller) chain(status *btcjson.GetBlockChainInfoResult, err error) *Component {
+	if err != nil {
+		return failed(err)
+	}
+	if c.genesis == nil {
+		return failed(errors.New("genesis block not verified"))
+	}
+
+	network := c.client.GetConfig().NetworkIdentifier.Network
+	if name, ok := client.ChainName(network); !ok || status.Chain != name {
+		return failed(errors.Errorf("node is on the %s chain, not on %s", status.Chain, network))
+	}
+
+	return &Component{Status: StatusOK}
+}
+
+func (c *Controller) indexer(nodeHeight *int64) *Component {
+	tip, err := c.blocks.GetTip()
+	if err == repository.ErrNotFound {
+		return &Component{
+			Status: StatusError,
+			Error:  "no block indexed yet",
+		}
+	}
+	if err != nil {
+		return failed(err)
+	}
+
+	component := &Component{
+		Status: StatusOK,
+		Height: &tip.Index,
+	}
+	if nodeHeight != nil && *nodeHeight-tip.Index > c.maxIndexerLag {
+		component.Status = StatusError
+		component.Error = "indexer is behind the node"
+	}
+
+	return component
+}
@@ -0,0 +1,133 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+func TestReadyz(t *testing.T) {
+	node := zcoindtest.NewNode()
+	for i := 0; i < 10; i++ {
+		node.Mine()
+	}
+	stub := zcoindtest.NewServer(node)
+	defer stub.Close()
+	down := zcoindtest.NewServer(zcoindtest.NewNode())
+	down.Close()
+
+	genesisHash, _ := client.GenesisHash(client.REGTEST)
+
+	// indexed is the height of the indexed tip, negative for an empty index
+	tests := []struct {
+		name    string
+		stub    *zcoindtest.Server
+		network string
+		indexed int64
+		want    map[string]string
+	}{
+		{
+			name:    "indexer at the tip",
+			stub:    stub,
+			indexed: 10,
+			want:    map[string]string{"node": StatusOK, "chain": StatusOK, "indexer": StatusOK},
+		},
+		{
+			name:    "indexer within the lag",
+			stub:    stub,
+			indexed: 10 - DefaultMaxIndexerLag,
+			want:    map[string]string{"node": StatusOK, "chain": StatusOK, "indexer": StatusOK},
+		},
+		{
+			name:    "indexer behind",
+			stub:    stub,
+			indexed: 9 - DefaultMaxIndexerLag,
+			want:    map[string]string{"node": StatusOK, "chain": StatusOK, "indexer": StatusError},
+		},
+		{
+			name:    "nothing indexed",
+			stub:    stub,
+			indexed: -1,
+			want:    map[string]string{"node": StatusOK, "chain": StatusOK, "indexer": StatusError},
+		},
+		{
+			name:    "node on another chain",
+			stub:    stub,
+			network: client.MAINNET,
+			indexed: 10,
+			want:    map[string]string{"node": StatusOK, "chain": StatusError, "indexer": StatusOK},
+		},
+		{
+			name:    "node unreachable",
+			stub:    down,
+			indexed: 10,
+			want:    map[string]string{"node": StatusError, "chain": StatusError, "indexer": StatusOK},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := zcoindtest.NewDatabase()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			blocks := repository.NewBlockProvider(db.BadgerDB)
+			if test.indexed >= 0 {
+				tip := &types.BlockIdentifier{Index: test.indexed, Hash: "tip"}
+				err := blocks.StoreBlock(tip.Hash, &types.BlockResponse{
+					Block: &types.Block{BlockIdentifier: tip, ParentBlockIdentifier: tip},
+				}, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg := test.stub.Config()
+			if test.network != "" {
+				cfg.NetworkIdentifier.Network = test.network
+			}
+			genesis := &types.BlockIdentifier{Index: 0, Hash: genesisHash}
+			controller := NewController(client.NewZcoinClient(cfg, zap.NewNop()), blocks, genesis, configuration.Health{}).(*Controller)
+			recorder := httptest.NewRecorder()
+			controller.Readyz(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			response := &Response{}
+			if err := json.NewDecoder(recorder.Body).Decode(response); err != nil {
+				t.Fatal(err)
+			}
+			wantStatus, wantCode := StatusOK, http.StatusOK
+			for name, status := range test.want {
+				if component := response.Components[name]; component == nil || component.Status != status {
+					t.Errorf("got %s %+v, want %s", name, component, status)
+				}
+				if status != StatusOK {
+					wantStatus, wantCode = StatusError, http.StatusServiceUnavailable
+				}
+			}
+			if response.Status != wantStatus || recorder.Code != wantCode {
+				t.Errorf("got %s with status %d, want %s with %d", response.Status, recorder.Code, wantStatus, wantCode)
+			}
+		})
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	down := zcoindtest.NewServer(zcoindtest.NewNode())
+	down.Close()
+
+	controller := NewController(client.NewZcoinClient(down.Config(), zap.NewNop()), nil, nil, configuration.Health{}).(*Controller)
+	recorder := httptest.NewRecorder()
+	controller.Healthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("got status %d with an unreachable node, want %d", recorder.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,186 @@
+package httpserver
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+const (
+	headerAllowOrigin = "Access-Control-Allow-Origin"
+	headerOrigin      = "Origin"
+	headerVary        = "Vary"
+	prefixCORS        = "Access-Control-"
+)
+
+var errRequestTooLarge = errors.New("http: request body too large")
+
+// Middleware wraps the Rosetta router with the request size limit and the
+// CORS policy of the configuration, no origin is allowed unless configured
+func Middleware(cfg configuration.Server, router http.Handler) http.Handler {
+	maxRequestSize := cfg.MaxRequestSize
+	if maxRequestSize <= 0 {
+		maxRequestSize = DefaultMaxRequestSize
+	}
+
+	return restrictOrigins(cfg.CORSOrigins, limitRequestSize(maxRequestSize, router))
+}
+
+func rejectTooLarge(w http.ResponseWriter) {
+	w.Header().Set("Connection", "close")
+	http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+}
+
+// limitRequestSize rejects request bodies larger than maxRequestSize bytes,
+// a body without a declared length is rejected once the handler reads past
+// the limit
+func limitRequestSize(maxRequestSize int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxRequestSize {
+			rejectTooLarge(w)
+			return
+		}
+
+		body := &limitedBody{ReadCloser: r.Body, remaining: maxRequestSize}
+		r.Body = body
+		next.ServeHTTP(&sizeWriter{ResponseWriter: w, body: body}, r)
+	})
+}
+
+// limitedBody fails the reads past its remaining bytes and records that the
+// limit was exceeded
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	if body.exceeded {
+		return 0, errRequestTooLarge
+	}
+	if int64(len(p)) > body.remaining+1 {
+		p = p[:body.remaining+1]
+	}
+
+	n, err := body.ReadCloser.Read(p)
+	if int64(n) <= body.remaining {
+		body.remaining -= int64(n)
+		return n, err
+	}
+
+	n = int(body.remaining)
+	body.remaining = 0
+	body.exceeded = true
+	return n, errRequestTooLarge
+}
+
+// sizeWriter replaces the response of a handler that read past the size
+// limit, whatever error it reports, with a 413
+type sizeWriter struct {
+	http.ResponseWriter
+	body        *limitedBody
+	wroteHeader bool
+	rejected    bool
+}
+
+func (w *sizeWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if w.body.exceeded {
+		w.rejected = true
+		w.Header().Del("Content-Length")
+		rejectTooLarge(w.ResponseWriter)
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sizeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.rejected {
+		return len(b), nil
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *sizeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	return hijacker.Hijack()
+}
+
+// originWriter replaces the allow-every-origin header the Rosetta router sets
+// with the request origin when it is allowed, and drops every CORS header
+// otherwise
+type originWriter struct {
+	http.ResponseWriter
+	origin      string
+	wroteHeader bool
+}
+
+func (w *originWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		header := w.Header()
+		if w.origin != "" {
+			header.Set(headerAllowOrigin, w.origin)
+		} else {
+			for name := range header {
+				if strings.HasPrefix(name, prefixCORS) {
+					header.Del(name)
+				}
+			}
+		}
+		header.Add(headerVary, headerOrigin)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *originWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *originWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	return hijacker.Hijack()
+}
+
+// restrictOrigins only allows the given origins to read the responses
+func restrictOrigins(origins []string, next http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		allowed[origin] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get(headerOrigin)
+		if !allowed[origin] {
+			origin = ""
+		}
+
+		next.ServeHTTP(&originWriter{ResponseWriter: w, origin: origin}, r)
+	})
+}
@@ -0,0 +1,105 @@
+package httpserver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+// echo stands in for the Rosetta router, it allows every origin and fails
+// when the body cannot be read like the generated controllers do
+var echo = server.CorsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(body)
+}))
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    configuration.Server
+		origin string
+		body   string
+		// chunked sends the body without declaring its length
+		chunked    bool
+		wantStatus int
+		wantOrigin string
+	}{
+		{
+			name:       "allowed origin",
+			cfg:        configuration.Server{CORSOrigins: []string{"https://explorer.example"}},
+			origin:     "https://explorer.example",
+			body:       "{}",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://explorer.example",
+		},
+		{
+			name:       "other origin",
+			cfg:        configuration.Server{CORSOrigins: []string{"https://explorer.example"}},
+			origin:     "https://elsewhere.example",
+			body:       "{}",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no origin without a list",
+			origin:     "https://elsewhere.example",
+			body:       "{}",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "body within the limit",
+			cfg:        configuration.Server{MaxRequestSize: 8},
+			body:       "12345678",
+			chunked:    true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "declared oversized body",
+			cfg:        configuration.Server{MaxRequestSize: 8},
+			body:       "123456789",
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "chunked oversized body",
+			cfg:        configuration.Server{MaxRequestSize: 8},
+			body:       "123456789",
+			chunked:    true,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/network/list", strings.NewReader(test.body))
+			if test.chunked {
+				request.ContentLength = -1
+			}
+			if test.origin != "" {
+				request.Header.Set(headerOrigin, test.origin)
+			}
+			recorder := httptest.NewRecorder()
+			Middleware(test.cfg, echo).ServeHTTP(recorder, request)
+
+			if recorder.Code != test.wantStatus {
+				t.Errorf("got status %d, want %d", recorder.Code, test.wantStatus)
+			}
+			if origin := recorder.Header().Get(headerAllowOrigin); origin != test.wantOrigin {
+				t.Errorf("got origin %q, want %q", origin, test.wantOrigin)
+			}
+			if test.wantOrigin == "" && recorder.Header().Get("Access-Control-Allow-Methods") != "" {
+				t.Errorf("got CORS headers %v for a disallowed origin", recorder.Header())
+			}
+			if test.wantStatus == http.StatusOK && recorder.Body.String() != test.body {
+				t.Errorf("got body %q, want %q", recorder.Body.String(), test.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package httpserver
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+// Defaults used for the settings left empty in the configuration
+const (
+	DefaultAddress        = "0.0.0.0"
+	DefaultReadTimeout    = 30 * time.Second
+	DefaultWriteTimeout   = 60 * time.Second
+	DefaultIdleTimeout    = 120 * time.Second
+	DefaultMaxRequestSize = 1 << 20
+)
+
+func orDefault(value time.Duration, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+// New creates the HTTP server serving handler with the configured address
+// and timeouts, the WebSocket connections are not bound by the timeouts
+// once upgraded
+func New(cfg configuration.Server, handler http.Handler) *http.Server {
+	address := cfg.Address
+	if address == "" {
+		address = DefaultAddress
+	}
+
+	return &http.Server{
+		Addr:         net.JoinHostPort(address, cfg.Port),
+		Handler:      handler,
+		ReadTimeout:  orDefault(cfg.ReadTimeout, DefaultReadTimeout),
+		WriteTimeout: orDefault(cfg.WriteTimeout, DefaultWriteTimeout),
+		IdleTimeout:  orDefault(cfg.IdleTimeout, DefaultIdleTimeout),
+	}
+}
+
+// ListenAndServe serves over TLS when a certificate and a key are configured
+func ListenAndServe(server *http.Server, cfg configuration.Server) error {
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return errors.New("tlsCertFile and tlsKeyFile have to be set together")
+	}
+	if cfg.TLSCertFile != "" {
+		return server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+
+	return server.ListenAndServe()
+}
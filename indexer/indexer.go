@@ -0,0 +1,434 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/metrics"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+)
+
+// DefaultPollInterval is used when no poll interval has been configured
+const DefaultPollInterval = 10 * time.Second
+
+const (
+	// relayQueueSize bounds the relayed transactions waiting to be looked up,
+	// the ones relayed while it is full are left to the next mempool poll
+	relayQueueSize = 1000
+
+	// relayLookupTimeout bounds the node call made for a relayed transaction
+	relayLookupTimeout = 10 * time.Second
+)
+
+// Listener is notified of every change the indexer makes. Calls come from the
+// indexer and notifier goroutines so implementations must be safe for
+// concurrent use and must not block.
+type Listener interface {
+	// BlockAdded is called with every block stored, transactions included.
+	BlockAdded(block *types.Block)
+
+	// BlockRemoved is called with every orphaned block, transactions included.
+	BlockRemoved(block *types.Block)
+
+	// TransactionAdded is called when a transaction enters the mempool.
+	TransactionAdded(hash string)
+
+	// TransactionRemoved is called when a transaction leaves the mempool.
+	TransactionRemoved(hash string)
+}
+
+// Indexer keeps the block repository and the mempool cache in sync with the node
+type Indexer struct {
+	client       client.ZcoinClient
+	repository   *repository.BlockProvider
+	mempool      *Mempool
+	znodes       *mapper.ZnodeCache
+	pollInterval time.Duration
+	trigger      chan struct{}
+	relayed      chan relayedTx
+	listeners    []Listener
+	logger       *zap.Logger
+	// reorging is set while blocks are being removed so a reorg is counted once
+	reorging bool
+}
+
+// New creates an indexer, it polls the node every pollInterval and syncs
+// immediately whenever a notifier reports a new block
+func New(
+	client client.ZcoinClient,
+	repository *repository.BlockProvider,
+	mempool *Mempool,
+	znodes *mapper.ZnodeCache,
+	pollInterval time.Duration,
+	logger *zap.Logger,
+) *Indexer {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	return &Indexer{
+		client:       client,
+		repository:   repository,
+		mempool:      mempool,
+		znodes:       znodes,
+		pollInterval: pollInterval,
+		trigger:      make(chan struct{}, 1),
+		relayed:      make(chan relayedTx, relayQueueSize),
+		logger:       logger,
+	}
+}
+
+// AddListener registers a listener, it has to be called before Start
+func (indexer *Indexer) AddListener(listener Listener) {
+	indexer.listeners = append(indexer.listeners, listener)
+}
+
+// BlockNotification schedules a sync, notifications arriving while one is
+// already pending are coalesced
+func (indexer *Indexer) BlockNotification(hash string) {
+	select {
+	case indexer.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// relayedTx is a transaction relayed by the node
+type relayedTx struct {
+	hash string
+	raw  []byte
+}
+
+// TransactionNotification queues a transaction relayed by the node for the
+// mempool cache, it never blocks the notifier. zcoind relays the transactions
+// of every block it connects as well, coinbases are skipped right away and
+// the node is asked about the others before they are added.
+func (indexer *Indexer) TransactionNotification(hash string, raw []byte) {
+	if _, ok := indexer.mempool.Get(hash); ok {
+		indexer.mempool.Add(hash, raw)
+		return
+	}
+	if isCoinbase(raw) {
+		return
+	}
+
+	select {
+	case indexer.relayed <- relayedTx{hash: hash, raw: raw}:
+	default:
+		indexer.logger.Debug("relay queue is full, leaving the transaction to the mempool poll", zap.String("hash", hash))
+	}
+}
+
+// relay adds the queued relayed transactions to the mempool cache until the
+// context is cancelled
+func (indexer *Indexer) relay(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tx := <-indexer.relayed:
+			indexer.addRelayed(ctx, tx)
+		}
+	}
+}
+
+// addRelayed adds a relayed transaction to the mempool cache unless the node
+// has already mined it
+func (indexer *Indexer) addRelayed(ctx context.Context, relayed relayedTx) {
+	if _, ok := indexer.mempool.Get(relayed.hash); ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, relayLookupTimeout)
+	defer cancel()
+	tx, err := indexer.client.GetRawTransaction(ctx, relayed.hash)
+	if rpcErr, ok := errors.Cause(err).(*btcjson.RPCError); ok && rpcErr.Code == btcjson.ErrRPCInvalidAddressOrKey {
+		// no longer in the mempool and the node keeps no transaction index
+		return
+	}
+	if err != nil {
+		indexer.logger.Warn("unable to look up relayed transaction", zap.String("hash", relayed.hash), zap.Error(err))
+		return
+	}
+	if tx.BlockHash != "" {
+		return
+	}
+
+	if indexer.mempool.Add(relayed.hash, relayed.raw) {
+		indexer.mempoolChanged([]string{relayed.hash}, nil)
+	}
+}
+
+// isCoinbase decodes just enough of a serialized transaction to recognize a
+// coinbase, privacy spends reference a null previous output as well
+func isCoinbase(raw []byte) bool {
+	msgTx := &wire.MsgTx{}
+	if err := msgTx.Deserialize(bytes.NewReader(raw)); err != nil || len(msgTx.TxIn) != 1 {
+		return false
+	}
+
+	in := msgTx.TxIn[0]
+	if in.PreviousOutPoint.Index != wire.MaxPrevOutIndex || in.PreviousOutPoint.Hash != (chainhash.Hash{}) {
+		return false
+	}
+	script := in.SignatureScript
+	return len(script) == 0 || (script[0] != client.OP_SIGMASPEND && script[0] != client.OP_LELANTUSJOINSPLIT)
+}
+
+// Start runs the indexer until the context is cancelled, a sync in progress
+// stops between two blocks. Polling keeps running even when notifications are
+// enabled since ZMQ does not guarantee delivery. Relayed transactions are
+// looked up on a separate goroutine.
+func (indexer *Indexer) Start(ctx context.Context) error {
+	ticker := time.NewTicker(indexer.pollInterval)
+	defer ticker.Stop()
+	go indexer.relay(ctx)
+
+	poll := true
+	for {
+		if err := indexer.Sync(ctx); err != nil && ctx.Err() == nil {
+			indexer.logger.Error("sync failed", zap.Error(err))
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if poll {
+			if err := indexer.RefreshMempool(ctx); err != nil && ctx.Err() == nil {
+				indexer.logger.Error("mempool refresh failed", zap.Error(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-indexer.trigger:
+			poll = false
+		case <-ticker.C:
+			poll = true
+		}
+	}
+}
+
+// RefreshMempool replaces the mempool cache with the node's current mempool
+func (indexer *Indexer) RefreshMempool(ctx context.Context) error {
+	hashes, err := indexer.client.GetRawMempool(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to get mempool")
+	}
+
+	indexer.mempoolChanged(indexer.mempool.Replace(hashes))
+	return nil
+}
+
+func (indexer *Indexer) mempoolChanged(added []string, removed []string) {
+	for _, listener := range indexer.listeners {
+		for _, hash := range added {
+			listener.TransactionAdded(hash)
+		}
+		for _, hash := range removed {
+			listener.TransactionRemoved(hash)
+		}
+	}
+}
+
+// Sync indexes every block between the indexed tip and the node's tip,
+// unwinding blocks that are no longer part of the main chain
+func (indexer *Indexer) Sync(ctx context.Context) error {
+	status, err := indexer.client.GetStatus(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to get node status")
+	}
+	nodeHeight := int64(status.Blocks)
+	metrics.NodeHeight.Set(float64(nodeHeight))
+
+	tip, err := indexer.rewind(ctx, nodeHeight)
+	if err != nil {
+		return err
+	}
+	if tip != nil {
+		metrics.IndexerHeight.Set(float64(tip.Index))
+	} else {
+		metrics.IndexerHeight.Set(-1)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		next := int64(0)
+		if tip != nil {
+			next = tip.Index + 1
+		}
+		if next > nodeHeight {
+			return nil
+		}
+
+		block, err := indexer.client.GetBlock(ctx, next)
+		if err != nil {
+			return errors.Wrapf(err, "unable to get block %d", next)
+		}
+
+		if tip != nil && block.PreviousHash != tip.Hash {
+			if tip, err = indexer.removeTip(tip); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := indexer.storeBlock(ctx, block); err != nil {
+			return err
+		}
+		indexer.mempoolChanged(nil, indexer.mempool.Remove(block.Tx...))
+		tip = &types.BlockIdentifier{Index: block.Height, Hash: block.Hash}
+	}
+}
+
+// rewind removes indexed blocks until the indexed tip is on the node's main chain
+func (indexer *Indexer) rewind(ctx context.Context, nodeHeight int64) (*types.BlockIdentifier, error) {
+	tip, err := indexer.repository.GetTip()
+	if err == repository.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get indexed tip")
+	}
+
+	for tip != nil {
+		if tip.Index <= nodeHeight {
+			block, err := indexer.client.GetBlock(ctx, tip.Index)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to get block %d", tip.Index)
+			}
+			if block.Hash == tip.Hash {
+				return tip, nil
+			}
+		}
+
+		if tip, err = indexer.removeTip(tip); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// storeBlock maps the transactions of a block so their operations can be
+// searched by account
+func (indexer *Indexer) storeBlock(ctx context.Context, block *btcjson.GetBlockVerboseResult) error {
+	verbose, err := indexer.client.GetBlockByHashWithTransaction(ctx, block.Hash)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get transactions of block %d", block.Height)
+	}
+
+	// blocks are stored with the same Znode annotations /block returns
+	znodes, err := indexer.znodes.Get(ctx, indexer.client, &types.BlockIdentifier{
+		Index: verbose.Height,
+		Hash:  verbose.Hash,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to get Znodes of block %d", block.Height)
+	}
+	mapped, err := mapper.Block(ctx, indexer.client, verbose, znodes, indexer.logger)
+	if err != nil {
+		return errors.Wrapf(err, "unable to map block %d", block.Height)
+	}
+
+	if err := indexer.repository.StoreBlock(block.Hash, mapBlock(block), mapped.Transactions); err != nil {
+		return errors.Wrapf(err, "unable to store block %d", block.Height)
+	}
+
+	indexer.reorging = false
+	metrics.IndexerHeight.Set(float64(block.Height))
+
+	for _, listener := range indexer.listeners {
+		listener.BlockAdded(mapped)
+	}
+	return nil
+}
+
+func (indexer *Indexer) removeTip(tip *types.BlockIdentifier) (*types.BlockIdentifier, error) {
+	indexer.logger.Info("removing orphaned block", zap.Int64("index", tip.Index), zap.String("hash", tip.Hash))
+
+	var orphaned *types.Block
+	if len(indexer.listeners) > 0 {
+		var err error
+		if orphaned, err = indexer.indexedBlock(tip); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := indexer.repository.RemoveBlock(tip.Hash); err != nil {
+		return nil, errors.Wrapf(err, "unable to remove block %d", tip.Index)
+	}
+	if !indexer.reorging {
+		indexer.reorging = true
+		metrics.Reorgs.Inc()
+	}
+	metrics.OrphanedBlocks.Inc()
+	metrics.IndexerHeight.Set(float64(tip.Index - 1))
+	for _, listener := range indexer.listeners {
+		listener.BlockRemoved(orphaned)
+	}
+
+	parent, err := indexer.repository.GetTip()
+	if err == repository.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get indexed tip")
+	}
+
+	return parent, nil
+}
+
+// indexedBlock reads a block and its transactions back from the repository
+func (indexer *Indexer) indexedBlock(identifier *types.BlockIdentifier) (*types.Block, error) {
+	block, err := indexer.repository.GetBlockByHash(identifier.Hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get block %d", identifier.Index)
+	}
+	transactions, err := indexer.repository.GetTransactions(identifier.Index)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get transactions of block %d", identifier.Index)
+	}
+
+	indexed := *block.Block
+	indexed.Transactions = transactions
+	return &indexed, nil
+}
+
+func mapBlock(block *btcjson.GetBlockVerboseResult) *types.BlockResponse {
+	parent := &types.BlockIdentifier{
+		Index: block.Height - 1,
+		Hash:  block.PreviousHash,
+	}
+	if block.Height == 0 {
+		parent = &types.BlockIdentifier{Index: 0, Hash: block.Hash}
+	}
+
+	transactions := make([]*types.TransactionIdentifier, 0, len(block.Tx))
+	for _, tx := range block.Tx {
+		transactions = append(transactions, &types.TransactionIdentifier{Hash: tx})
+	}
+
+	return &types.BlockResponse{
+		Block: &types.Block{
+			BlockIdentifier: &types.BlockIdentifier{
+				Index: block.Height,
+				Hash:  block.Hash,
+			},
+			ParentBlockIdentifier: parent,
+			Timestamp:             block.Time * 1000, // ms
+		},
+		OtherTransactions: transactions,
+	}
+}
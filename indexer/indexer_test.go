@@ -0,0 +1,284 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/metrics"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+type recordingListener struct {
+	mu      sync.Mutex
+	added   []string
+	blocks  int
+	orphans int
+}
+
+func (listener *recordingListener) TransactionRemoved(hash string) {}
+
+func (listener *recordingListener) BlockAdded(block *types.Block) {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	listener.blocks++
+}
+
+func (listener *recordingListener) BlockRemoved(block *types.Block) {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	listener.orphans++
+}
+
+func (listener *recordingListener) TransactionAdded(hash string) {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	listener.added = append(listener.added, hash)
+}
+
+// serialize builds a transaction spending the given outpoint, a coinbase when
+// prevHash is zero and index is wire.MaxPrevOutIndex
+func serialize(t *testing.T, prevHash chainhash.Hash, index uint32, script []byte) (string, []byte) {
+	msgTx := wire.NewMsgTx(1)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, index), script, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e8, []byte{0x51}))
+
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return msgTx.TxHash().String(), buf.Bytes()
+}
+
+func send(t *testing.T, node *zcoindtest.Node, raw []byte) {
+	param, _ := json.Marshal(hex.EncodeToString(raw))
+	if _, err := node.Call("sendrawtransaction", []json.RawMessage{param}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionNotification(t *testing.T) {
+	spent := chainhash.DoubleHashH([]byte("funding"))
+
+	tests := []struct {
+		name string
+		// prepare returns the notifications to deliver
+		prepare func(t *testing.T, node *zcoindtest.Node) [][]byte
+		added   int
+	}{
+		{
+			name: "mempool transaction",
+			prepare: func(t *testing.T, node *zcoindtest.Node) [][]byte {
+				_, raw := serialize(t, spent, 0, nil)
+				send(t, node, raw)
+				return [][]byte{raw}
+			},
+			added: 1,
+		},
+		{
+			name: "relayed twice",
+			prepare: func(t *testing.T, node *zcoindtest.Node) [][]byte {
+				_, raw := serialize(t, spent, 1, nil)
+				send(t, node, raw)
+				return [][]byte{raw, raw}
+			},
+			added: 1,
+		},
+		{
+			name: "mined transaction",
+			prepare: func(t *testing.T, node *zcoindtest.Node) [][]byte {
+				_, raw := serialize(t, spent, 2, nil)
+				send(t, node, raw)
+				node.Mine()
+				return [][]byte{raw}
+			},
+		},
+		{
+			name: "coinbase",
+			prepare: func(t *testing.T, node *zcoindtest.Node) [][]byte {
+				_, raw := serialize(t, chainhash.Hash{}, wire.MaxPrevOutIndex, []byte{0x51})
+				return [][]byte{raw}
+			},
+		},
+		{
+			name: "unknown transaction",
+			prepare: func(t *testing.T, node *zcoindtest.Node) [][]byte {
+				_, raw := serialize(t, spent, 3, nil)
+				return [][]byte{raw}
+			},
+		},
+		{
+			name: "joinsplit",
+			prepare: func(t *testing.T, node *zcoindtest.Node) [][]byte {
+				_, raw := serialize(t, chainhash.Hash{}, wire.MaxPrevOutIndex, []byte{client.OP_LELANTUSJOINSPLIT})
+				send(t, node, raw)
+				return [][]byte{raw}
+			},
+			added: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := zcoindtest.NewServer(zcoindtest.NewNode())
+			defer stub.Close()
+
+			zcoinClient := client.NewZcoinClient(stub.Config(), zap.NewNop())
+			indexer := New(zcoinClient, nil, NewMempool(), nil, 0, zap.NewNop())
+			listener := &recordingListener{}
+			indexer.AddListener(listener)
+
+			for _, raw := range test.prepare(t, stub.Node) {
+				indexer.TransactionNotification(chainhash.DoubleHashH(raw).String(), raw)
+			}
+			for len(indexer.relayed) > 0 {
+				indexer.addRelayed(context.Background(), <-indexer.relayed)
+			}
+
+			if len(listener.added) != test.added {
+				t.Errorf("got %d mempool additions, want %d", len(listener.added), test.added)
+			}
+			if got := len(indexer.mempool.Hashes()); got != test.added {
+				t.Errorf("got %d cached transactions, want %d", got, test.added)
+			}
+		})
+	}
+}
+
+func TestTransactionNotificationQueueFull(t *testing.T) {
+	// nothing drains the queue, notifications beyond its size are dropped
+	stub := zcoindtest.NewServer(zcoindtest.NewNode())
+	defer stub.Close()
+	indexer := New(client.NewZcoinClient(stub.Config(), zap.NewNop()), nil, NewMempool(), nil, 0, zap.NewNop())
+	spent := chainhash.DoubleHashH([]byte("funding"))
+	for i := uint32(0); i <= relayQueueSize; i++ {
+		hash, raw := serialize(t, spent, i, nil)
+		indexer.TransactionNotification(hash, raw)
+	}
+
+	if queued := len(indexer.relayed); queued != relayQueueSize {
+		t.Errorf("got %d queued transactions, want %d", queued, relayQueueSize)
+	}
+}
+
+// newIndexer indexes the blocks of the node into an empty database
+func newIndexer(t *testing.T, stub *zcoindtest.Server) (*Indexer, *repository.BlockProvider, func()) {
+	db, err := zcoindtest.NewDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := repository.NewBlockProvider(db.BadgerDB)
+	zcoinClient := client.NewZcoinClient(stub.Config(), zap.NewNop())
+
+	return New(zcoinClient, blocks, NewMempool(), mapper.NewZnodeCache(), 0, zap.NewNop()), blocks, func() { db.Close() }
+}
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		name string
+		// reorg orphans that many blocks of the indexed chain before mining
+		reorg   int
+		mined   int
+		blocks  int
+		orphans int
+	}{
+		{"new blocks", 0, 2, 2, 0},
+		{"nothing new", 0, 0, 0, 0},
+		{"longer fork", 2, 3, 3, 2},
+		{"fork of the same height", 1, 1, 1, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := zcoindtest.NewNode()
+			for i := 0; i < 3; i++ {
+				node.Mine()
+			}
+			stub := zcoindtest.NewServer(node)
+			defer stub.Close()
+			indexer, blocks, closeDB := newIndexer(t, stub)
+			defer closeDB()
+			ctx := context.Background()
+			if err := indexer.Sync(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			listener := &recordingListener{}
+			indexer.AddListener(listener)
+			if test.reorg > 0 {
+				node.Reorg(test.reorg)
+			}
+			for i := 0; i < test.mined; i++ {
+				node.Mine()
+			}
+			if err := indexer.Sync(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			tip, err := blocks.GetTip()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := node.BlockAt(node.Height()); tip.Index != want.Height || tip.Hash != want.Hash {
+				t.Errorf("indexed up to %+v, want %d %s", tip, want.Height, want.Hash)
+			}
+			if listener.blocks != test.blocks || listener.orphans != test.orphans {
+				t.Errorf("got %d blocks and %d orphans, want %d and %d", listener.blocks, listener.orphans, test.blocks, test.orphans)
+			}
+			if height := testutil.ToFloat64(metrics.IndexerHeight); height != float64(tip.Index) {
+				t.Errorf("got indexer height %v, want %d", height, tip.Index)
+			}
+		})
+	}
+}
+
+func TestStartStops(t *testing.T) {
+	node := zcoindtest.NewNode()
+	for i := 0; i < 20; i++ {
+		node.Mine()
+	}
+	stub := zcoindtest.NewServer(node)
+	defer stub.Close()
+	indexer, blocks, closeDB := newIndexer(t, stub)
+	defer closeDB()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- indexer.Start(ctx)
+	}()
+	cancel()
+	select {
+	case err := <-stopped:
+		if err != context.Canceled {
+			t.Errorf("stopped with %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("indexer did not stop")
+	}
+
+	// the indexer stops between two blocks
+	tip, err := blocks.GetTip()
+	if err == repository.ErrNotFound {
+		return
+	}
+	if err != nil || node.BlockAt(tip.Index).Hash != tip.Hash {
+		t.Errorf("stopped on %+v, not a block of the node: %v", tip, err)
+	}
+}
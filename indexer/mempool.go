@@ -0,0 +1,100 @@
+package indexer
+
+import (
+	"sort"
+	"sync"
+)
+
+// Mempool caches the transactions currently known to be in the node's mempool
+type Mempool struct {
+	mu  sync.RWMutex
+	txs map[string][]byte
+}
+
+// NewMempool creates an empty mempool cache
+func NewMempool() *Mempool {
+	return &Mempool{
+		txs: make(map[string][]byte),
+	}
+}
+
+// Add caches a transaction, raw may be nil when only the hash is known. It
+// returns true when the transaction was not cached yet.
+func (m *Mempool) Add(hash string, raw []byte) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	known, ok := m.txs[hash]
+	if raw == nil && known != nil {
+		return false
+	}
+	m.txs[hash] = raw
+	return !ok
+}
+
+// Remove evicts the given transactions, typically once they are mined, and
+// returns the ones that were cached
+func (m *Mempool) Remove(hashes ...string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed []string
+	for _, hash := range hashes {
+		if _, ok := m.txs[hash]; ok {
+			delete(m.txs, hash)
+			removed = append(removed, hash)
+		}
+	}
+
+	return removed
+}
+
+// Replace resets the cache to the given set of hashes while keeping the raw
+// transactions that are already known, it returns the hashes that entered and
+// left the cache
+func (m *Mempool) Replace(hashes []string) (added []string, removed []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	txs := make(map[string][]byte, len(hashes))
+	for _, hash := range hashes {
+		raw, ok := m.txs[hash]
+		if !ok {
+			added = append(added, hash)
+		}
+		txs[hash] = raw
+	}
+	for hash := range m.txs {
+		if _, ok := txs[hash]; !ok {
+			removed = append(removed, hash)
+		}
+	}
+	m.txs = txs
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// Get returns the raw transaction and whether the hash is in the mempool
+func (m *Mempool) Get(hash string) ([]byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	raw, ok := m.txs[hash]
+	return raw, ok
+}
+
+// Hashes returns the sorted hashes of all cached transactions
+func (m *Mempool) Hashes() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hashes := make([]string, 0, len(m.txs))
+	for hash := range m.txs {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	return hashes
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	badger "github.com/dgraph-io/badger"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/httpserver"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/provider"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/services"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/subscription"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/webhook"
+)
+
+// shutdownTimeout bounds the time in-flight requests are given to complete
+const shutdownTimeout = 30 * time.Second
+
+// components runs the background components of the gateway, they all stop
+// once the context they were started with is cancelled
+type components struct {
+	wg     sync.WaitGroup
+	logger *zap.Logger
+}
+
+// start runs a component in its own goroutine
+func (c *components) start(ctx context.Context, name string, run func(context.Context) error) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		if err := run(ctx); err != nil && err != context.Canceled {
+			c.logger.Error("component failed", zap.String("component", name), zap.Error(err))
+			return
+		}
+		c.logger.Info("component stopped", zap.String("component", name))
+	}()
+}
+
+// wait blocks until every component has stopped
+func (c *components) wait() {
+	c.wg.Wait()
+}
+
+// run serves the gateway until SIGINT or SIGTERM is received or the server
+// fails. In-flight requests are then drained, the background components are
+// stopped, the indexer between two blocks, and the database is closed last.
+func run(cfg *configuration.Config, logger *zap.Logger) error {
+	client := client.NewZcoinClient(cfg, logger.Named("client"))
+
+	genesis, err := services.VerifyGenesisBlock(context.Background(), client)
+	if err != nil {
+		return errors.Wrapf(err, "node is not on the %s chain", cfg.NetworkIdentifier.Network)
+	}
+
+	db, err := provider.ProvideDatabase(badger.DefaultOptions(cfg.Indexer.DatabasePath))
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			logger.Error("failed to close database", zap.Error(err))
+			return
+		}
+		logger.Info("database closed")
+	}()
+	blocks := repository.NewBlockProvider(db)
+	webhooks := repository.NewWebhookProvider(db)
+
+	// the components are stopped before the database is closed
+	ctx, cancel := context.WithCancel(context.Background())
+	background := &components{logger: logger}
+	defer func() {
+		cancel()
+		background.wait()
+	}()
+
+	hub := subscription.NewHub(cfg.Subscriptions.BufferSize, logger.Named("subscription"))
+	dispatcher := webhook.New(webhooks, cfg.Webhooks, logger.Named("webhook"))
+	background.start(ctx, "webhooks", dispatcher.Start)
+	// the indexer and the API share the Znode lists of the recent blocks
+	znodes := mapper.NewZnodeCache()
+	// the indexer moves the tip of the mapped blocks cache and evicts orphans
+	blockCache := mapper.NewBlockResponseCache(cfg.Cache.Size, cfg.Cache.ConfirmationDepth)
+	startIndexer(ctx, background, cfg, client, blocks, znodes, logger, hub, dispatcher, blockCache)
+
+	router := NewBlockchainRouter(client, blocks, znodes, blockCache, webhooks, hub, genesis, logger.Named("server"))
+	httpServer := httpserver.New(cfg.Server, router)
+	// hijacked WebSocket connections are not tracked by the server
+	httpServer.RegisterOnShutdown(hub.Close)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpserver.ListenAndServe(httpServer, cfg.Server)
+	}()
+	logger.Info("listening", zap.String("address", httpServer.Addr), zap.Bool("tls", cfg.Server.TLSCertFile != ""))
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-serveErr:
+		return errors.Wrap(err, "Zcoin Rosetta Gateway server exited suddenly")
+	case sig := <-signals:
+		logger.Info("shutting down", zap.Stringer("signal", sig))
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed to drain requests", zap.Error(err))
+	}
+
+	return nil
+}
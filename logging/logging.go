@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+type contextKey struct{}
+
+// New creates the application logger, an empty level logs from info and an
+// empty format writes JSON
+func New(cfg configuration.Logging) (*zap.Logger, error) {
+	level := zap.NewAtomicLevel()
+	if cfg.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, errors.Wrapf(err, "invalid log level %q", cfg.Level)
+		}
+	}
+
+	zapConfig := zap.NewProductionConfig()
+	zapConfig.Level = level
+	zapConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	switch cfg.Format {
+	case "", "json":
+	case "console":
+		zapConfig.Encoding = "console"
+	default:
+		return nil, errors.Errorf("invalid log format %q", cfg.Format)
+	}
+
+	return zapConfig.Build()
+}
+
+// NewContext returns a context carrying logger
+func NewContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the request logger carried by ctx, fallback when the
+// call does not come from a request
+func FromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+
+	return fallback
+}
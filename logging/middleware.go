@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader carries the request ID, a client may set it to correlate
+// its own logs with the gateway ones
+const RequestIDHeader = "X-Request-ID"
+
+// statusWriter records the status code of a response, it can still be
+// hijacked for the WebSocket subscriptions
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	w.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// Middleware assigns a request ID to every request, echoes it in the response
+// and makes a logger tagged with it available through FromContext
+func Middleware(logger *zap.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		requestLogger := logger.With(zap.String("request_id", requestID))
+		writer := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(writer, r.WithContext(NewContext(r.Context(), requestLogger)))
+
+		requestLogger.Info("request served",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", writer.status),
+			zap.Duration("duration", time.Since(start)),
+		)
+	})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -8,30 +9,99 @@ import (
 	"github.com/coinbase/rosetta-sdk-go/asserter"
 	"github.com/coinbase/rosetta-sdk-go/server"
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
 	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
 	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/health"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/httpserver"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/indexer"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/logging"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/metrics"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/notifier"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
 	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/services"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/subscription"
 )
 
 // NewBlockchainRouter creates a blockchain specific router
 // that will handle common routes specified inside the rosetta API specification
-func NewBlockchainRouter(client client.ZcoinClient) http.Handler {
-	assert, err := asserter.NewServer([]*types.NetworkIdentifier{
-		{
-			Blockchain:           client.GetConfig().NetworkIdentifier.Blockchain,
-			Network:              client.GetConfig().NetworkIdentifier.Network,
-			SubNetworkIdentifier: nil,
+func NewBlockchainRouter(
+	zcoinClient client.ZcoinClient,
+	blocks *repository.BlockProvider,
+	znodes *mapper.ZnodeCache,
+	blockCache *mapper.BlockResponseCache,
+	webhooks *repository.WebhookProvider,
+	hub *subscription.Hub,
+	genesis *types.BlockIdentifier,
+	logger *zap.Logger,
+) http.Handler {
+	assert, err := asserter.NewServer(
+		client.OperationTypes,
+		true,
+		[]*types.NetworkIdentifier{
+			{
+				Blockchain:           zcoinClient.GetConfig().NetworkIdentifier.Blockchain,
+				Network:              zcoinClient.GetConfig().NetworkIdentifier.Network,
+				SubNetworkIdentifier: nil,
+			},
 		},
-	})
+	)
 
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: Failed to create asserter: %v\n", err)
-		os.Exit(1)
+		logger.Fatal("failed to create asserter", zap.Error(err))
+	}
+
+	cfg := zcoinClient.GetConfig()
+	networkAPIController := server.NewNetworkAPIController(services.NewNetworkAPIService(zcoinClient, blockCache, genesis, logger), assert)
+	blockAPIController := server.NewBlockAPIController(services.NewBlockAPIService(zcoinClient, znodes, blockCache, logger), assert)
+	accountAPIController := server.NewAccountAPIController(services.NewAccountAPIService(zcoinClient, znodes, blocks, logger), assert)
+	mempoolAPIController := server.NewMempoolAPIController(services.NewMempoolAPIService(zcoinClient, znodes, logger), assert)
+	searchAPIController := services.NewSearchAPIController(services.NewSearchAPIService(zcoinClient, blocks, logger), assert)
+	eventsAPIController := services.NewEventsAPIController(services.NewEventsAPIService(zcoinClient, blocks, logger), assert)
+	webhooksAPIController := services.NewWebhooksAPIController(services.NewWebhooksAPIService(zcoinClient, webhooks, logger), assert)
+	router := server.NewRouter(
+		metrics.Instrument(networkAPIController),
+		metrics.Instrument(blockAPIController),
+		metrics.Instrument(accountAPIController),
+		metrics.Instrument(mempoolAPIController),
+		metrics.Instrument(searchAPIController),
+		metrics.Instrument(eventsAPIController),
+		metrics.Instrument(webhooksAPIController),
+		// a subscription request lasts as long as its connection, it is not timed
+		subscription.NewController(hub, assert),
+		metrics.NewController(),
+		health.NewController(zcoinClient, blocks, genesis, cfg.Health),
+	)
+
+	return logging.Middleware(logger, httpserver.Middleware(cfg.Server, router))
+}
+
+// startIndexer keeps the block repository in sync with the node, driven by
+// ZMQ notifications when they are configured and by polling otherwise
+func startIndexer(
+	ctx context.Context,
+	background *components,
+	cfg *configuration.Config,
+	zcoinClient client.ZcoinClient,
+	blocks *repository.BlockProvider,
+	znodes *mapper.ZnodeCache,
+	logger *zap.Logger,
+	listeners ...indexer.Listener,
+) *indexer.Indexer {
+	idx := indexer.New(zcoinClient, blocks, indexer.NewMempool(), znodes, cfg.Indexer.PollInterval, logger.Named("indexer"))
+	for _, listener := range listeners {
+		idx.AddListener(listener)
+	}
+	background.start(ctx, "indexer", idx.Start)
+
+	if cfg.ZMQ.Enabled() {
+		background.start(ctx, "notifier", notifier.NewZMQNotifier(cfg.ZMQ, idx, logger.Named("notifier")).Start)
+	} else {
+		logger.Info("ZMQ not configured, polling the node", zap.Duration("interval", cfg.Indexer.PollInterval))
 	}
 
-	networkAPIController := server.NewNetworkAPIController(services.NewNetworkAPIService(client), assert)
-	blockAPIController := server.NewBlockAPIController(services.NewBlockAPIService(client), assert)
-	return server.NewRouter(networkAPIController, blockAPIController)
+	return idx
 }
 
 func main() {
@@ -46,12 +116,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	client := client.NewZcoinClient(cfg)
-	router := NewBlockchainRouter(client)
-	fmt.Println("Listening on ", "0.0.0.0:"+cfg.Server.Port)
-	err = http.ListenAndServe("0.0.0.0:"+cfg.Server.Port, router)
+	logger, err := logging.New(cfg.Logging)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Zcoin Rosetta Gateway server exited suddenly: %v\n", err)
+		fmt.Fprintf(os.Stderr, "ERROR: Failed to create logger: %v\n", err)
 		os.Exit(1)
 	}
+	defer logger.Sync()
+	// the Rosetta SDK logs through the standard logger
+	zap.RedirectStdLog(logger)
+
+	if err := run(cfg, logger); err != nil {
+		logger.Fatal("gateway stopped", zap.Error(err))
+	}
 }
@@ -0,0 +1,107 @@
+package mapper
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+)
+
+// BlockIdentifier returns the identifier of a zcoind block
+func BlockIdentifier(block *client.GetBlockVerboseTxResult) *types.BlockIdentifier {
+	return &types.BlockIdentifier{
+		Index: block.Height,
+		Hash:  block.Hash,
+	}
+}
+
+// ParentBlockIdentifier returns the identifier of the parent of a zcoind
+// block, the genesis block is its own parent
+func ParentBlockIdentifier(block *client.GetBlockVerboseTxResult) *types.BlockIdentifier {
+	if block.Height == 0 {
+		return BlockIdentifier(block)
+	}
+
+	return &types.BlockIdentifier{
+		Index: block.Height - 1,
+		Hash:  block.PreviousHash,
+	}
+}
+
+// NewBlockCache returns a transaction cache primed with the transactions of
+// the block, inputs spending outputs created in the same block are common
+func NewBlockCache(block *client.GetBlockVerboseTxResult) TransactionCache {
+	cache := make(TransactionCache, len(block.Tx))
+	for i := range block.Tx {
+		cache[block.Tx[i].Txid] = &block.Tx[i]
+	}
+
+	return cache
+}
+
+// claimFees debits the coinbase from the fee pool by the fees the other
+// transactions of its block credited to it
+func claimFees(coinbase *types.Transaction, transactions []*types.Transaction) {
+	var total int64
+	for _, transaction := range transactions {
+		for _, operation := range transaction.Operations {
+			if operation.Type == client.ActionTypeFee {
+				value, _ := strconv.ParseInt(operation.Amount.Value, 10, 64)
+				total += value
+			}
+		}
+	}
+	if total == 0 {
+		return
+	}
+
+	coinbase.Operations = append(coinbase.Operations, feeOperation(int64(len(coinbase.Operations)), -total))
+}
+
+// Block maps a zcoind block and all of its transactions into a Rosetta block,
+// including Elysium token operations when enabled. The coinbase claims the
+// fees of the block from the fee pool.
+func Block(
+	ctx context.Context,
+	zcoinClient client.ZcoinClient,
+	block *client.GetBlockVerboseTxResult,
+	znodes *Znodes,
+	logger *zap.Logger,
+) (*types.Block, error) {
+	cache := NewBlockCache(block)
+	elysiumTxs, err := ElysiumBlock(ctx, zcoinClient, block.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]*types.Transaction, 0, len(block.Tx))
+	for i := range block.Tx {
+		transaction, err := Transaction(ctx, zcoinClient, &block.Tx[i], cache, znodes, logger)
+		if err != nil {
+			return nil, err
+		}
+		if err := elysiumTxs.Apply(ctx, zcoinClient, transaction, logger); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if len(block.Tx) > 0 && len(block.Tx[0].Vin) == 1 && block.Tx[0].Vin[0].IsCoinBase() {
+		claimFees(transactions[0], transactions[1:])
+	}
+
+	metadata, err := Metadata(blockMetadata(block))
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Block{
+		BlockIdentifier:       BlockIdentifier(block),
+		ParentBlockIdentifier: ParentBlockIdentifier(block),
+		Timestamp:             block.Time * 1000, // ms
+		Transactions:          transactions,
+		Metadata:              metadata,
+	}, nil
+}
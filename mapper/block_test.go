@@ -0,0 +1,78 @@
+package mapper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+// feeOperations returns the fee operations of a transaction
+func feeOperations(transaction *types.Transaction) []*types.Operation {
+	var operations []*types.Operation
+	for _, operation := range transaction.Operations {
+		if operation.Type == client.ActionTypeFee {
+			operations = append(operations, operation)
+		}
+	}
+
+	return operations
+}
+
+func TestBlockFees(t *testing.T) {
+	node := zcoindtest.NewNode()
+	funding := node.Mine().Tx[0]
+	block := node.Mine(
+		zcoindtest.Transaction(
+			[]client.Vin{zcoindtest.Input(&funding, 0)},
+			zcoindtest.Output(zcoindtest.DefaultMinerAddress, 49.99),
+		),
+		zcoindtest.Transaction(
+			[]client.Vin{zcoindtest.JoinSplit(0.02)},
+			zcoindtest.Output(zcoindtest.DefaultMinerAddress, 1),
+		),
+	)
+
+	stub := zcoindtest.NewServer(node)
+	defer stub.Close()
+	zcoinClient := client.NewZcoinClient(stub.Config(), zap.NewNop())
+
+	mapped, err := Block(context.Background(), zcoinClient, block, nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		transaction *types.Transaction
+		value       string
+	}{
+		{name: "coinbase claim", transaction: mapped.Transactions[0], value: "-3000000"},
+		{name: "transfer", transaction: mapped.Transactions[1], value: "1000000"},
+		{name: "joinsplit", transaction: mapped.Transactions[2], value: "2000000"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fees := feeOperations(test.transaction)
+			if len(fees) != 1 {
+				t.Fatalf("got %d fee operations, want 1", len(fees))
+			}
+
+			fee := fees[0]
+			if fee.Account.Address != client.FEE_POOL_ADDRESS || fee.Amount.Value != test.value {
+				t.Errorf("got %s %s, want %s %s", fee.Account.Address, fee.Amount.Value, client.FEE_POOL_ADDRESS, test.value)
+			}
+			if fee.OperationIdentifier.NetworkIndex != nil {
+				t.Errorf("fee operation has network index %d", *fee.OperationIdentifier.NetworkIndex)
+			}
+			last := test.transaction.Operations[len(test.transaction.Operations)-1]
+			if last != fee {
+				t.Errorf("fee operation is not the last operation")
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package mapper
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/metrics"
+)
+
+const (
+	// DefaultBlockCacheSize is used when no cache size has been configured
+	DefaultBlockCacheSize = 256
+	// DefaultConfirmationDepth is used when no confirmation depth has been configured
+	DefaultConfirmationDepth = 6
+)
+
+const blockCacheName = "block"
+
+// BlockResponseCache is a bounded LRU of mapped blocks, it follows the indexer
+// as a listener. Any cached block can be looked up by hash. Blocks known to be
+// on the main chain can be looked up by height as well, once they are buried
+// under the confirmation depth of the indexed tip. The tip of the node is not
+// used, it may already be past blocks the indexer has yet to orphan.
+type BlockResponseCache struct {
+	mu       sync.Mutex
+	size     int
+	depth    int64
+	tip      *types.BlockIdentifier
+	entries  *list.List
+	byHash   map[string]*list.Element
+	byHeight map[int64]*list.Element
+}
+
+// NewBlockResponseCache creates a cache holding up to size blocks, blocks are
+// final once depth blocks have been mined on top of them
+func NewBlockResponseCache(size int, depth int64) *BlockResponseCache {
+	if size <= 0 {
+		size = DefaultBlockCacheSize
+	}
+	if depth <= 0 {
+		depth = DefaultConfirmationDepth
+	}
+
+	return &BlockResponseCache{
+		size:     size,
+		depth:    depth,
+		entries:  list.New(),
+		byHash:   make(map[string]*list.Element),
+		byHeight: make(map[int64]*list.Element),
+	}
+}
+
+// isFinal requires the lock to be held
+func (cache *BlockResponseCache) isFinal(index int64) bool {
+	return cache.tip != nil && cache.tip.Index-index >= cache.depth
+}
+
+// remove requires the lock to be held
+func (cache *BlockResponseCache) remove(element *list.Element) {
+	block := cache.entries.Remove(element).(*types.Block)
+	delete(cache.byHash, block.BlockIdentifier.Hash)
+	if cache.byHeight[block.BlockIdentifier.Index] == element {
+		delete(cache.byHeight, block.BlockIdentifier.Index)
+	}
+}
+
+// add requires the lock to be held
+func (cache *BlockResponseCache) add(block *types.Block, mainChain bool) {
+	if element, ok := cache.byHash[block.BlockIdentifier.Hash]; ok {
+		cache.remove(element)
+	}
+	if element, ok := cache.byHeight[block.BlockIdentifier.Index]; ok && mainChain {
+		cache.remove(element)
+	}
+
+	element := cache.entries.PushFront(block)
+	cache.byHash[block.BlockIdentifier.Hash] = element
+	if mainChain {
+		cache.byHeight[block.BlockIdentifier.Index] = element
+	}
+
+	for cache.entries.Len() > cache.size {
+		cache.remove(cache.entries.Back())
+	}
+}
+
+// Get returns the cached block with the given hash, or nil
+func (cache *BlockResponseCache) Get(hash string) *types.Block {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, ok := cache.byHash[hash]
+	metrics.CacheLookup(blockCacheName, ok)
+	if !ok {
+		return nil
+	}
+	cache.entries.MoveToFront(element)
+
+	return element.Value.(*types.Block)
+}
+
+// GetByIndex returns the main chain block at the given height if it is final
+// at the current tip, or nil
+func (cache *BlockResponseCache) GetByIndex(index int64) *types.Block {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, ok := cache.byHeight[index]
+	ok = ok && cache.isFinal(index)
+	metrics.CacheLookup(blockCacheName, ok)
+	if !ok {
+		return nil
+	}
+	cache.entries.MoveToFront(element)
+
+	return element.Value.(*types.Block)
+}
+
+// Add caches a block mapped from the node, evicting the least recently used
+// one when full. Only blocks already final are taken as the main chain ones,
+// a block near the tip may still be orphaned before the indexer sees it.
+func (cache *BlockResponseCache) Add(block *types.Block) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.add(block, cache.isFinal(block.BlockIdentifier.Index))
+}
+
+// BlockAdded caches a block of the main chain and moves the tip to it
+func (cache *BlockResponseCache) BlockAdded(block *types.Block) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.tip = block.BlockIdentifier
+	cache.add(block, true)
+}
+
+// BlockRemoved evicts an orphaned block and moves the tip back to its parent
+func (cache *BlockResponseCache) BlockRemoved(block *types.Block) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.tip = block.ParentBlockIdentifier
+	if element, ok := cache.byHash[block.BlockIdentifier.Hash]; ok {
+		cache.remove(element)
+	}
+}
+
+// TransactionAdded is a no-op, mempool transactions are not cached
+func (cache *BlockResponseCache) TransactionAdded(hash string) {}
+
+// TransactionRemoved is a no-op, mempool transactions are not cached
+func (cache *BlockResponseCache) TransactionRemoved(hash string) {}
@@ -0,0 +1,121 @@
+package mapper
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+func cacheBlock(index int64, hash string) *types.Block {
+	return &types.Block{
+		BlockIdentifier:       &types.BlockIdentifier{Index: index, Hash: hash},
+		ParentBlockIdentifier: &types.BlockIdentifier{Index: index - 1},
+	}
+}
+
+func TestBlockResponseCache(t *testing.T) {
+	tests := []struct {
+		name string
+		// update feeds the cache with a chain of blocks 0 to 4, a depth of 2
+		update    func(cache *BlockResponseCache)
+		index     int64
+		wantIndex string
+		hash      string
+		wantHash  bool
+	}{
+		{
+			name:      "indexed block near the tip",
+			index:     3,
+			hash:      "3",
+			wantHash:  true,
+			wantIndex: "",
+		},
+		{
+			name:      "indexed block becoming final",
+			update:    func(cache *BlockResponseCache) { cache.BlockAdded(cacheBlock(5, "5")) },
+			index:     3,
+			wantIndex: "3",
+			hash:      "3",
+			wantHash:  true,
+		},
+		{
+			name: "tip moved back by a reorg",
+			update: func(cache *BlockResponseCache) {
+				cache.BlockRemoved(cacheBlock(4, "4"))
+				cache.BlockRemoved(cacheBlock(3, "3"))
+			},
+			index:    1,
+			hash:     "1",
+			wantHash: true,
+		},
+		{
+			name: "orphaned block",
+			update: func(cache *BlockResponseCache) {
+				cache.BlockRemoved(cacheBlock(4, "4"))
+				cache.BlockAdded(cacheBlock(4, "4b"))
+				cache.BlockAdded(cacheBlock(5, "5b"))
+				cache.BlockAdded(cacheBlock(6, "6b"))
+			},
+			index:     4,
+			wantIndex: "4b",
+			hash:      "4",
+		},
+		{
+			name: "block from the node near the tip",
+			update: func(cache *BlockResponseCache) {
+				cache.BlockRemoved(cacheBlock(3, "3"))
+				cache.Add(cacheBlock(3, "3b"))
+				cache.BlockAdded(cacheBlock(5, "5"))
+			},
+			index:    3,
+			hash:     "3b",
+			wantHash: true,
+		},
+		{
+			name:      "block from the node already final",
+			update:    func(cache *BlockResponseCache) { cache.Add(cacheBlock(1, "1b")) },
+			index:     1,
+			wantIndex: "1b",
+			hash:      "1b",
+			wantHash:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := NewBlockResponseCache(10, 2)
+			for i := int64(0); i <= 4; i++ {
+				cache.BlockAdded(cacheBlock(i, fmt.Sprintf("%d", i)))
+			}
+			if test.update != nil {
+				test.update(cache)
+			}
+
+			var gotIndex string
+			if block := cache.GetByIndex(test.index); block != nil {
+				gotIndex = block.BlockIdentifier.Hash
+			}
+			if gotIndex != test.wantIndex {
+				t.Errorf("got %q at height %d, want %q", gotIndex, test.index, test.wantIndex)
+			}
+			if gotHash := cache.Get(test.hash) != nil; gotHash != test.wantHash {
+				t.Errorf("got cached %t for %s, want %t", gotHash, test.hash, test.wantHash)
+			}
+		})
+	}
+}
+
+func TestBlockResponseCacheEviction(t *testing.T) {
+	cache := NewBlockResponseCache(2, 1)
+	for i := int64(0); i <= 2; i++ {
+		cache.BlockAdded(cacheBlock(i, fmt.Sprintf("%d", i)))
+	}
+
+	if cache.Get("0") != nil || cache.GetByIndex(0) != nil {
+		t.Error("got the least recently used block, want it evicted")
+	}
+	if cache.GetByIndex(1) == nil || cache.Get("2") == nil {
+		t.Error("got the recent blocks evicted, want them cached")
+	}
+}
@@ -0,0 +1,312 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/logging"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/metrics"
+)
+
+// ElysiumCurrency returns the currency of an Elysium property, the property
+// ID is kept in the metadata
+func ElysiumCurrency(propertyID int64, divisible bool) *types.Currency {
+	var decimals int32
+	if divisible {
+		decimals = client.ELYSIUM_DIVISIBLE_UNITS
+	}
+
+	return &types.Currency{
+		Symbol:   fmt.Sprintf("%s%d", client.ELYSIUM_SYMBOL_PREFIX, propertyID),
+		Decimals: decimals,
+		Metadata: map[string]interface{}{
+			"propertyId": propertyID,
+		},
+	}
+}
+
+// ElysiumAmount converts an Elysium decimal string into token units, negated
+// for debits
+func ElysiumAmount(value string, currency *types.Currency, negate bool) (*types.Amount, error) {
+	parts := strings.SplitN(value, ".", 2)
+	fraction := ""
+	if len(parts) == 2 {
+		fraction = parts[1]
+	}
+	if len(fraction) > int(currency.Decimals) {
+		return nil, errors.Errorf("amount %s has more than %d decimals", value, currency.Decimals)
+	}
+
+	units, ok := new(big.Int).SetString(parts[0]+fraction+strings.Repeat("0", int(currency.Decimals)-len(fraction)), 10)
+	if !ok {
+		return nil, errors.Errorf("invalid amount %s", value)
+	}
+	if negate {
+		units.Neg(units)
+	}
+
+	return &types.Amount{
+		Value:    units.String(),
+		Currency: currency,
+	}, nil
+}
+
+// ElysiumBalance maps an Elysium balance of a property with the given
+// divisibility
+func ElysiumBalance(balance *client.ElysiumBalance, divisible bool) (*types.Amount, error) {
+	return ElysiumAmount(balance.Balance, ElysiumCurrency(balance.PropertyID, divisible), false)
+}
+
+// ElysiumProperties keeps the divisibility of the Elysium properties, it never
+// changes once a property is created
+type ElysiumProperties struct {
+	mu        sync.Mutex
+	divisible map[int64]bool
+}
+
+// NewElysiumProperties returns an empty property cache
+func NewElysiumProperties() *ElysiumProperties {
+	return &ElysiumProperties{
+		divisible: make(map[int64]bool),
+	}
+}
+
+// Divisible returns whether a property is divisible, the node is only asked
+// the first time a property is seen
+func (properties *ElysiumProperties) Divisible(ctx context.Context, zcoinClient client.ZcoinClient, propertyID int64) (bool, error) {
+	properties.mu.Lock()
+	divisible, ok := properties.divisible[propertyID]
+	properties.mu.Unlock()
+	metrics.CacheLookup("elysium_property", ok)
+	if ok {
+		return divisible, nil
+	}
+
+	property, err := zcoinClient.GetElysiumProperty(ctx, propertyID)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to get Elysium property %d", propertyID)
+	}
+
+	properties.mu.Lock()
+	properties.divisible[propertyID] = property.Divisible
+	properties.mu.Unlock()
+	return property.Divisible, nil
+}
+
+// ElysiumTransactions holds the hashes of the Elysium transactions of a block
+type ElysiumTransactions map[string]bool
+
+// ElysiumBlock returns the Elysium transactions of the block at height, none
+// when the token layer is disabled
+func ElysiumBlock(ctx context.Context, zcoinClient client.ZcoinClient, height int64) (ElysiumTransactions, error) {
+	if !zcoinClient.GetConfig().Elysium.Enabled {
+		return ElysiumTransactions{}, nil
+	}
+
+	hashes, err := zcoinClient.ListElysiumBlockTransactions(ctx, height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list Elysium transactions of block %d", height)
+	}
+
+	txs := make(ElysiumTransactions, len(hashes))
+	for _, hash := range hashes {
+		txs[hash] = true
+	}
+
+	return txs, nil
+}
+
+// elysiumNeutralTypes are the Elysium transaction types that move no tokens
+var elysiumNeutralTypes = map[int64]bool{
+	client.ELYSIUM_CREATE_VARIABLE:  true,
+	client.ELYSIUM_CLOSE_CROWDSALE:  true,
+	client.ELYSIUM_CREATE_MANAGED:   true,
+	client.ELYSIUM_CHANGE_ISSUER:    true,
+	client.ELYSIUM_ENABLE_FREEZING:  true,
+	client.ELYSIUM_DISABLE_FREEZING: true,
+	client.ELYSIUM_FREEZE_TOKENS:    true,
+	client.ELYSIUM_UNFREEZE_TOKENS:  true,
+}
+
+// elysiumOperations appends the token operations of an Elysium transaction,
+// credits relate to the last debit
+type elysiumOperations struct {
+	transaction *types.Transaction
+	status      string
+	elysiumType string
+	sender      []*types.OperationIdentifier
+}
+
+func (ops *elysiumOperations) add(opType string, address string, value string, currency *types.Currency, negate bool) error {
+	amount, err := ElysiumAmount(value, currency, negate)
+	if err != nil {
+		return err
+	}
+
+	ops.addAmount(opType, address, amount)
+	return nil
+}
+
+func (ops *elysiumOperations) addAmount(opType string, address string, amount *types.Amount) {
+	operation := &types.Operation{
+		OperationIdentifier: &types.OperationIdentifier{
+			Index: int64(len(ops.transaction.Operations)),
+		},
+		Type:   opType,
+		Status: ops.status,
+		Account: &types.AccountIdentifier{
+			Address: address,
+		},
+		Amount: amount,
+		Metadata: map[string]interface{}{
+			"elysiumType": ops.elysiumType,
+		},
+		RelatedOperations: ops.sender,
+	}
+	ops.transaction.Operations = append(ops.transaction.Operations, operation)
+	if strings.HasPrefix(amount.Value, "-") {
+		ops.sender = []*types.OperationIdentifier{operation.OperationIdentifier}
+	}
+}
+
+func (ops *elysiumOperations) send(from string, to string, value string, currency *types.Currency) error {
+	if err := ops.add(client.ElysiumSend, from, value, currency, true); err != nil {
+		return err
+	}
+	return ops.add(client.ElysiumSend, to, value, currency, false)
+}
+
+// Apply appends the token operations of an Elysium transaction, invalid
+// transactions keep their operations with a failed status. Receivers relate
+// to the sender of a send. Types moving tokens the gateway cannot map are
+// logged and left without operations.
+func (txs ElysiumTransactions) Apply(ctx context.Context, zcoinClient client.ZcoinClient, transaction *types.Transaction, logger *zap.Logger) error {
+	hash := transaction.TransactionIdentifier.Hash
+	if !txs[hash] {
+		return nil
+	}
+
+	tx, err := zcoinClient.GetElysiumTransaction(ctx, hash)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get Elysium transaction %s", hash)
+	}
+
+	status := client.StatusSuccess
+	if !tx.Valid {
+		status = client.StatusFail
+	}
+	ops := &elysiumOperations{
+		transaction: transaction,
+		status:      status,
+		elysiumType: tx.Type,
+	}
+	currency := ElysiumCurrency(tx.PropertyID, tx.Divisible)
+
+	switch tx.TypeInt {
+	case client.ELYSIUM_SIMPLE_SEND:
+		if err := ops.send(tx.SendingAddress, tx.ReferenceAddress, tx.Amount, currency); err != nil {
+			return err
+		}
+		if tx.PurchasedPropertyID == 0 {
+			return nil
+		}
+
+		// a send to an active crowdsale buys tokens, the issuer may get a bonus
+		purchased := ElysiumCurrency(tx.PurchasedPropertyID, tx.PurchasedPropertyDivisible)
+		ops.sender = nil
+		if err := ops.add(client.ElysiumIssuance, tx.SendingAddress, tx.PurchasedTokens, purchased, false); err != nil {
+			return err
+		}
+		bonus, err := ElysiumAmount(tx.IssuerTokens, purchased, false)
+		if err != nil || bonus.Value == "0" {
+			return err
+		}
+		ops.addAmount(client.ElysiumIssuance, tx.ReferenceAddress, bonus)
+		return nil
+
+	case client.ELYSIUM_SEND_TO_OWNERS:
+		return ops.sendToOwners(ctx, zcoinClient, tx, currency)
+
+	case client.ELYSIUM_SEND_ALL:
+		for _, subSend := range tx.SubSends {
+			subCurrency := ElysiumCurrency(subSend.PropertyID, subSend.Divisible)
+			if err := ops.send(tx.SendingAddress, tx.ReferenceAddress, subSend.Amount, subCurrency); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case client.ELYSIUM_CREATE_FIXED:
+		return ops.add(client.ElysiumIssuance, tx.SendingAddress, tx.Amount, currency, false)
+
+	case client.ELYSIUM_GRANT_TOKENS:
+		receiver := tx.ReferenceAddress
+		if receiver == "" {
+			receiver = tx.SendingAddress
+		}
+		return ops.add(client.ElysiumIssuance, receiver, tx.Amount, currency, false)
+
+	case client.ELYSIUM_REVOKE_TOKENS:
+		return ops.add(client.ElysiumRevoke, tx.SendingAddress, tx.Amount, currency, true)
+	}
+
+	if tx.Valid && !elysiumNeutralTypes[tx.TypeInt] {
+		logging.FromContext(ctx, logger).Warn("unsupported Elysium transaction type, its token movements are not mapped",
+			zap.String("hash", hash),
+			zap.Int64("type", tx.TypeInt),
+			zap.String("name", tx.Type),
+		)
+	}
+	return nil
+}
+
+// sendToOwners debits the sender of what every owner of the property got and
+// of the fee, paid with the native token of the property's ecosystem. The
+// recipients of an invalid send are unknown, the amount requested is kept as
+// a failed debit.
+func (ops *elysiumOperations) sendToOwners(ctx context.Context, zcoinClient client.ZcoinClient, tx *client.ElysiumTransaction, currency *types.Currency) error {
+	if !tx.Valid {
+		return ops.add(client.ElysiumSend, tx.SendingAddress, tx.Amount, currency, true)
+	}
+
+	sto, err := zcoinClient.GetElysiumSTO(ctx, tx.Txid)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get recipients of Elysium transaction %s", tx.Txid)
+	}
+
+	sent := new(big.Int)
+	credits := make([]*types.Amount, 0, len(sto.Recipients))
+	for _, recipient := range sto.Recipients {
+		amount, err := ElysiumAmount(recipient.Amount, currency, false)
+		if err != nil {
+			return err
+		}
+		value, _ := new(big.Int).SetString(amount.Value, 10)
+		sent.Add(sent, value)
+		credits = append(credits, amount)
+	}
+	ops.addAmount(client.ElysiumSend, tx.SendingAddress, &types.Amount{
+		Value:    sent.Neg(sent).String(),
+		Currency: currency,
+	})
+	for i, recipient := range sto.Recipients {
+		ops.addAmount(client.ElysiumSend, recipient.Address, credits[i])
+	}
+
+	if sto.TotalSTOFee == "" {
+		return nil
+	}
+	feeToken := int64(client.ELYSIUM_MAIN_TOKEN)
+	if tx.PropertyID >= client.ELYSIUM_TEST_ECOSYSTEM {
+		feeToken = client.ELYSIUM_TEST_TOKEN
+	}
+	ops.sender = nil
+	return ops.add(client.ElysiumFee, tx.SendingAddress, sto.TotalSTOFee, ElysiumCurrency(feeToken, true), true)
+}
@@ -0,0 +1,206 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+func TestElysiumApply(t *testing.T) {
+	const alice, bob, carol = "alice", "bob", "carol"
+	tests := []struct {
+		name    string
+		payload client.ElysiumTransaction
+		want    []string
+		logged  int
+	}{
+		{
+			name: "crowdsale purchase",
+			payload: client.ElysiumTransaction{
+				SendingAddress:      alice,
+				ReferenceAddress:    bob,
+				TypeInt:             client.ELYSIUM_SIMPLE_SEND,
+				Type:                "Crowdsale Purchase",
+				PropertyID:          1,
+				Divisible:           true,
+				Amount:              "10.00000000",
+				PurchasedPropertyID: 5,
+				PurchasedTokens:     "100",
+				IssuerTokens:        "10",
+				Valid:               true,
+			},
+			want: []string{
+				"elysium_send alice -1000000000 ELYSIUM-1",
+				"elysium_send bob 1000000000 ELYSIUM-1",
+				"elysium_issuance alice 100 ELYSIUM-5",
+				"elysium_issuance bob 10 ELYSIUM-5",
+			},
+		},
+		{
+			name: "send to owners",
+			payload: client.ElysiumTransaction{
+				SendingAddress: alice,
+				TypeInt:        client.ELYSIUM_SEND_TO_OWNERS,
+				Type:           "Send To Owners",
+				PropertyID:     3,
+				Divisible:      true,
+				Amount:         "10.00000001",
+				TotalSTOFee:    "0.00000002",
+				Recipients: []client.ElysiumRecipient{
+					{Address: bob, Amount: "2.50000000"},
+					{Address: carol, Amount: "7.50000000"},
+				},
+				Valid: true,
+			},
+			want: []string{
+				"elysium_send alice -1000000000 ELYSIUM-3",
+				"elysium_send bob 250000000 ELYSIUM-3",
+				"elysium_send carol 750000000 ELYSIUM-3",
+				"elysium_fee alice -2 ELYSIUM-1",
+			},
+		},
+		{
+			name: "invalid send to owners",
+			payload: client.ElysiumTransaction{
+				SendingAddress: alice,
+				TypeInt:        client.ELYSIUM_SEND_TO_OWNERS,
+				Type:           "Send To Owners",
+				PropertyID:     3,
+				Divisible:      true,
+				Amount:         "10.00000000",
+			},
+			want: []string{"elysium_send alice -1000000000 ELYSIUM-3"},
+		},
+		{
+			name: "send all",
+			payload: client.ElysiumTransaction{
+				SendingAddress:   alice,
+				ReferenceAddress: bob,
+				TypeInt:          client.ELYSIUM_SEND_ALL,
+				Type:             "Send All",
+				SubSends: []client.ElysiumSubSend{
+					{PropertyID: 3, Divisible: true, Amount: "1.00000000"},
+					{PropertyID: 5, Amount: "4"},
+				},
+				Valid: true,
+			},
+			want: []string{
+				"elysium_send alice -100000000 ELYSIUM-3",
+				"elysium_send bob 100000000 ELYSIUM-3",
+				"elysium_send alice -4 ELYSIUM-5",
+				"elysium_send bob 4 ELYSIUM-5",
+			},
+		},
+		{
+			name: "unsupported type",
+			payload: client.ElysiumTransaction{
+				SendingAddress: alice,
+				TypeInt:        25,
+				Type:           "MetaDEx trade",
+				PropertyID:     3,
+				Divisible:      true,
+				Amount:         "1.00000000",
+				Valid:          true,
+			},
+			logged: 1,
+		},
+		{
+			name: "type moving no tokens",
+			payload: client.ElysiumTransaction{
+				SendingAddress: alice,
+				TypeInt:        client.ELYSIUM_CREATE_MANAGED,
+				Type:           "Create Property - Manual",
+				PropertyID:     6,
+				Valid:          true,
+			},
+		},
+	}
+
+	node := zcoindtest.NewNode()
+	stub := zcoindtest.NewServer(node)
+	defer stub.Close()
+	zcoinClient := client.NewZcoinClient(stub.Config(), zap.NewNop())
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hash := fmt.Sprintf("elysium%d", i)
+			node.AddElysiumTransaction(&client.TxRawResult{Txid: hash}, test.payload)
+			transaction := &types.Transaction{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: hash},
+			}
+
+			core, logs := observer.New(zap.WarnLevel)
+			if err := (ElysiumTransactions{hash: true}).Apply(context.Background(), zcoinClient, transaction, zap.New(core)); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(transaction.Operations) != len(test.want) {
+				t.Fatalf("got %d operations, want %d", len(transaction.Operations), len(test.want))
+			}
+			for j, op := range transaction.Operations {
+				if got := fmt.Sprintf("%s %s %s %s", op.Type, op.Account.Address, op.Amount.Value, op.Amount.Currency.Symbol); got != test.want[j] {
+					t.Errorf("operation %d is %q, want %q", j, got, test.want[j])
+				}
+			}
+			if logs.Len() != test.logged {
+				t.Errorf("logged %d entries, want %d", logs.Len(), test.logged)
+			}
+		})
+	}
+}
+
+func TestElysiumProperties(t *testing.T) {
+	node := zcoindtest.NewNode()
+	node.AddElysiumTransaction(&client.TxRawResult{Txid: "create"}, client.ElysiumTransaction{
+		TypeInt:    client.ELYSIUM_CREATE_FIXED,
+		PropertyID: 5,
+		Amount:     "1000",
+		Valid:      true,
+	})
+	stub := zcoindtest.NewServer(node)
+	defer stub.Close()
+	counting := &countingClient{ZcoinClient: client.NewZcoinClient(stub.Config(), zap.NewNop())}
+	properties := NewElysiumProperties()
+
+	tests := []struct {
+		propertyID int64
+		want       bool
+	}{
+		{propertyID: client.ELYSIUM_MAIN_TOKEN, want: true},
+		{propertyID: 5},
+		{propertyID: 5},
+	}
+	for _, test := range tests {
+		divisible, err := properties.Divisible(context.Background(), counting, test.propertyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if divisible != test.want {
+			t.Errorf("property %d divisible: %t, want %t", test.propertyID, divisible, test.want)
+		}
+	}
+	if counting.properties != 2 {
+		t.Errorf("got %d property lookups, want 2", counting.properties)
+	}
+
+	if _, err := properties.Divisible(context.Background(), counting, 7); err == nil {
+		t.Error("got the divisibility of an unknown property")
+	}
+}
+
+// countingClient counts the property lookups
+type countingClient struct {
+	client.ZcoinClient
+	properties int
+}
+
+func (c *countingClient) GetElysiumProperty(ctx context.Context, propertyID int64) (*client.ElysiumProperty, error) {
+	c.properties++
+	return c.ZcoinClient.GetElysiumProperty(ctx, propertyID)
+}
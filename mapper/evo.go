@@ -0,0 +1,50 @@
+package mapper
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/logging"
+)
+
+// specialTxMetadata sets the special transaction type and its decoded extra
+// payload. A payload that fails to decode is logged and kept in its raw form.
+func specialTxMetadata(
+	ctx context.Context,
+	zcoinClient client.ZcoinClient,
+	tx *client.TxRawResult,
+	metadata *TransactionMetadata,
+	logger *zap.Logger,
+) {
+	txType := tx.SpecialTxType()
+	metadata.Type = client.SpecialTxName(txType)
+	if tx.ExtraPayload == "" {
+		return
+	}
+
+	metadata.ExtraPayload = tx.ExtraPayload
+	params := client.ChainParams(zcoinClient.GetConfig().NetworkIdentifier.Network)
+	payload, err := client.DecodeSpecialTx(txType, tx.ExtraPayload, params)
+	if err != nil {
+		logging.FromContext(ctx, logger).Error("unable to decode special transaction payload",
+			zap.String("hash", tx.Txid),
+			zap.String("type", metadata.Type),
+			zap.Error(err),
+		)
+		return
+	}
+
+	switch payload := payload.(type) {
+	case *client.ProRegTx:
+		metadata.ProRegTx = payload
+	case *client.ProUpServTx:
+		metadata.ProUpServTx = payload
+	case *client.ProUpRegTx:
+		metadata.ProUpRegTx = payload
+	case *client.ProUpRevTx:
+		metadata.ProUpRevTx = payload
+	case *client.CbTx:
+		metadata.CbTx = payload
+	}
+}
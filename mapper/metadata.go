@@ -0,0 +1,155 @@
+package mapper
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+)
+
+// TransactionMetadata is the metadata of every mapped transaction, fees are
+// in satoshis and fee rates in satoshis per virtual byte. The virtual size and
+// weight are only set when the node reports them.
+type TransactionMetadata struct {
+	Version      int32   `json:"version"`
+	Size         int32   `json:"size"`
+	VSize        int32   `json:"vsize,omitempty"`
+	Weight       int32   `json:"weight,omitempty"`
+	LockTime     uint32  `json:"lockTime"`
+	Type         string  `json:"type"`
+	InputCount   int     `json:"inputCount"`
+	OutputCount  int     `json:"outputCount"`
+	Fee          int64   `json:"fee"`
+	FeeRate      float64 `json:"feeRate"`
+	Coinbase     bool    `json:"coinbase"`
+	Privacy      bool    `json:"privacy"`
+	ExtraPayload string  `json:"extraPayload,omitempty"`
+
+	ProRegTx    *client.ProRegTx    `json:"proRegTx,omitempty"`
+	ProUpServTx *client.ProUpServTx `json:"proUpServTx,omitempty"`
+	ProUpRegTx  *client.ProUpRegTx  `json:"proUpRegTx,omitempty"`
+	ProUpRevTx  *client.ProUpRevTx  `json:"proUpRevTx,omitempty"`
+	CbTx        *client.CbTx        `json:"cbTx,omitempty"`
+}
+
+// BlockMetadata is the metadata of every mapped block, MTP and ProgPoW fields
+// are only set for blocks mined with those algorithms
+type BlockMetadata struct {
+	Version      int32   `json:"version"`
+	VersionHex   string  `json:"versionHex"`
+	MerkleRoot   string  `json:"merkleRoot"`
+	Bits         string  `json:"bits"`
+	Nonce        uint32  `json:"nonce"`
+	Difficulty   float64 `json:"difficulty"`
+	ChainWork    string  `json:"chainWork,omitempty"`
+	MedianTime   int64   `json:"medianTime,omitempty"`
+	Size         int32   `json:"size"`
+	StrippedSize int32   `json:"strippedSize"`
+	Weight       int32   `json:"weight"`
+	TxCount      int     `json:"txCount"`
+
+	MTPVersion   string `json:"mtpVersion,omitempty"`
+	MTPHashValue string `json:"mtpHashValue,omitempty"`
+	MTPReserved0 string `json:"mtpReserved0,omitempty"`
+	MTPReserved1 string `json:"mtpReserved1,omitempty"`
+
+	Nonce64 uint64 `json:"nonce64,omitempty"`
+	MixHash string `json:"mixHash,omitempty"`
+}
+
+// Metadata converts a typed metadata struct into a Rosetta metadata map, going
+// through JSON so every endpoint serializes it the same way
+func Metadata(value interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// blockMetadata describes the header and size of a block
+func blockMetadata(block *client.GetBlockVerboseTxResult) *BlockMetadata {
+	return &BlockMetadata{
+		Version:      block.Version,
+		VersionHex:   block.VersionHex,
+		MerkleRoot:   block.MerkleRoot,
+		Bits:         block.Bits,
+		Nonce:        block.Nonce,
+		Difficulty:   block.Difficulty,
+		ChainWork:    block.ChainWork,
+		MedianTime:   block.MedianTime,
+		Size:         block.Size,
+		StrippedSize: block.StrippedSize,
+		Weight:       block.Weight,
+		TxCount:      len(block.Tx),
+		MTPVersion:   block.MTPVersion,
+		MTPHashValue: block.MTPHashValue,
+		MTPReserved0: block.MTPReserved0,
+		MTPReserved1: block.MTPReserved1,
+		Nonce64:      block.Nonce64,
+		MixHash:      block.MixHash,
+	}
+}
+
+// operationsFee is the XZC the inputs and outputs of a transaction do not
+// account for, fee operations are left out
+func operationsFee(operations []*types.Operation) int64 {
+	sum := new(big.Int)
+	for _, operation := range operations {
+		if operation.Type == client.ActionTypeFee || operation.Amount.Currency.Symbol != Currency.Symbol {
+			continue
+		}
+		value, _ := new(big.Int).SetString(operation.Amount.Value, 10)
+		sum.Add(sum, value)
+	}
+
+	return -sum.Int64()
+}
+
+// transactionMetadata describes a transaction, the fee is whatever XZC its
+// operations do not account for
+func transactionMetadata(
+	ctx context.Context,
+	zcoinClient client.ZcoinClient,
+	tx *client.TxRawResult,
+	operations []*types.Operation,
+	coinbase bool,
+	privacy bool,
+	logger *zap.Logger,
+) *TransactionMetadata {
+	metadata := &TransactionMetadata{
+		Version:     tx.Version,
+		Size:        tx.Size,
+		VSize:       tx.Vsize,
+		Weight:      tx.Weight,
+		LockTime:    tx.LockTime,
+		InputCount:  len(tx.Vin),
+		OutputCount: len(tx.Vout),
+		Coinbase:    coinbase,
+		Privacy:     privacy,
+	}
+
+	if !coinbase {
+		metadata.Fee = operationsFee(operations)
+	}
+	// without segwit the virtual size is the size
+	vsize := metadata.VSize
+	if vsize == 0 {
+		vsize = metadata.Size
+	}
+	if vsize > 0 {
+		metadata.FeeRate = float64(metadata.Fee) / float64(vsize)
+	}
+
+	specialTxMetadata(ctx, zcoinClient, tx, metadata, logger)
+	return metadata
+}
@@ -0,0 +1,262 @@
+package mapper
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+var (
+	update       = flag.Bool("update", false, "record the fixtures and golden blocks from the mainnet node of -config")
+	configPath   = flag.String("config", os.Getenv(configuration.ConfigPath), "gateway configuration of the mainnet node to record from")
+	searchFrom   = flag.Int64("search-from", -1, "height the search for the replayed blocks starts at, the tip by default")
+	searchBlocks = flag.Int64("search-blocks", 50000, "number of blocks searched for the replayed blocks")
+)
+
+var (
+	fixtureDir   = filepath.Join("testdata", "fixtures")
+	manifestPath = filepath.Join("testdata", "blocks.json")
+	goldenPath   = filepath.Join("testdata", "blocks.golden.json")
+)
+
+// replayedBlock is a mainnet block kept for its transactions of one kind
+type replayedBlock struct {
+	Kind   string `json:"kind"`
+	Height int64  `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// replayKinds are the transactions a mainnet block is recorded for, each
+// match reports whether a block holds one
+var replayKinds = []struct {
+	name  string
+	match func(ctx context.Context, zcoinClient client.ZcoinClient, block *client.GetBlockVerboseTxResult) (bool, error)
+}{
+	{name: "sigma spend", match: hasInput((*client.Vin).IsSigmaSpend)},
+	{name: "lelantus joinsplit", match: hasInput((*client.Vin).IsLelantusJoinSplit)},
+	{name: "znode payout", match: hasZnodePayout},
+	{name: "protx", match: hasProTx},
+	{name: "elysium send", match: hasElysiumSend},
+}
+
+func hasInput(is func(*client.Vin) bool) func(context.Context, client.ZcoinClient, *client.GetBlockVerboseTxResult) (bool, error) {
+	return func(ctx context.Context, zcoinClient client.ZcoinClient, block *client.GetBlockVerboseTxResult) (bool, error) {
+		for _, tx := range block.Tx {
+			for i := range tx.Vin {
+				if is(&tx.Vin[i]) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+}
+
+func hasZnodePayout(ctx context.Context, zcoinClient client.ZcoinClient, block *client.GetBlockVerboseTxResult) (bool, error) {
+	znodes, err := NewZnodeCache().Get(ctx, zcoinClient, BlockIdentifier(block))
+	if err != nil {
+		return false, err
+	}
+
+	for _, out := range block.Tx[0].Vout {
+		for _, address := range out.ScriptPubKey.Addresses {
+			if len(znodes.Payout(address)) > 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func hasProTx(ctx context.Context, zcoinClient client.ZcoinClient, block *client.GetBlockVerboseTxResult) (bool, error) {
+	for i := range block.Tx {
+		if txType := block.Tx[i].SpecialTxType(); txType >= client.PROVIDER_REGISTER && txType <= client.PROVIDER_UPDATE_REVOKE {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func hasElysiumSend(ctx context.Context, zcoinClient client.ZcoinClient, block *client.GetBlockVerboseTxResult) (bool, error) {
+	hashes, err := zcoinClient.ListElysiumBlockTransactions(ctx, block.Height)
+	if err != nil {
+		return false, err
+	}
+
+	for _, hash := range hashes {
+		tx, err := zcoinClient.GetElysiumTransaction(ctx, hash)
+		if err != nil {
+			return false, err
+		}
+		if tx.Valid && tx.TypeInt == client.ELYSIUM_SIMPLE_SEND {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// searchReplayBlocks walks down the mainnet chain until a block of every
+// kind has been found, the calls made are not recorded
+func searchReplayBlocks(ctx context.Context, t *testing.T, zcoinClient client.ZcoinClient) []replayedBlock {
+	from := *searchFrom
+	if from < 0 {
+		status, err := zcoinClient.GetStatus(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from = int64(status.Blocks)
+	}
+
+	found := make([]replayedBlock, 0, len(replayKinds))
+	matched := make(map[string]bool)
+	for height := from; height > from-*searchBlocks && height > 0 && len(found) < len(replayKinds); height-- {
+		header, err := zcoinClient.GetBlock(ctx, height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, err := zcoinClient.GetBlockByHashWithTransaction(ctx, header.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, kind := range replayKinds {
+			if matched[kind.name] {
+				continue
+			}
+			ok, err := kind.match(ctx, zcoinClient, block)
+			if err != nil {
+				t.Fatalf("unable to look for a %s in block %d: %v", kind.name, height, err)
+			}
+			if ok {
+				matched[kind.name] = true
+				found = append(found, replayedBlock{Kind: kind.name, Height: height, Hash: block.Hash})
+			}
+		}
+	}
+
+	if len(found) < len(replayKinds) {
+		var missing []string
+		for _, kind := range replayKinds {
+			if !matched[kind.name] {
+				missing = append(missing, kind.name)
+			}
+		}
+		t.Fatalf("no %s in blocks %d to %d, widen the search with -search-from and -search-blocks", strings.Join(missing, ", "), from-*searchBlocks+1, from)
+	}
+	return found
+}
+
+// recordReplayBlocks looks up the replayed blocks on the mainnet node of
+// -config and records the calls made to map them
+func recordReplayBlocks(ctx context.Context, t *testing.T) (client.ZcoinClient, []replayedBlock) {
+	if *configPath == "" {
+		t.Fatalf("-update records from a mainnet node, set its gateway configuration with -config or %s", configuration.ConfigPath)
+	}
+	cfg, err := configuration.New(*configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NetworkIdentifier.Network != client.MAINNET {
+		t.Fatalf("fixtures are recorded from mainnet, %s is configured for %s", *configPath, cfg.NetworkIdentifier.Network)
+	}
+	cfg.Node.RecordPath = ""
+	cfg.Elysium.Enabled = true
+
+	zcoinClient := client.NewZcoinClient(cfg, zap.NewNop())
+	blocks := searchReplayBlocks(ctx, t, zcoinClient)
+
+	manifest, err := json.MarshalIndent(blocks, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(fixtureDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(manifestPath, append(manifest, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return client.NewZcoinClientRecorder(zcoinClient, fixtureDir, zap.NewNop()), blocks
+}
+
+// TestBlockReplay maps mainnet blocks from recorded node responses, run with
+// -update -config <mainnet gateway configuration> to record them again
+func TestBlockReplay(t *testing.T) {
+	ctx := context.Background()
+	cfg := &configuration.Config{
+		NetworkIdentifier: configuration.NetworkIdentifier{
+			Blockchain: "Zcoin",
+			Network:    client.MAINNET,
+		},
+		Elysium: configuration.Elysium{Enabled: true},
+	}
+	var zcoinClient client.ZcoinClient = client.NewZcoinClientReplay(cfg, fixtureDir)
+	var replayed []replayedBlock
+
+	if *update {
+		zcoinClient, replayed = recordReplayBlocks(ctx, t)
+	} else {
+		manifest, err := ioutil.ReadFile(manifestPath)
+		if os.IsNotExist(err) {
+			t.Skipf("no mainnet blocks recorded in %s yet, run go test -run TestBlockReplay -update -config <mainnet gateway configuration>", manifestPath)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(manifest, &replayed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	znodes := NewZnodeCache()
+	mapped := make(map[string]*types.Block, len(replayed))
+	for _, replay := range replayed {
+		header, err := zcoinClient.GetBlock(ctx, replay.Height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Hash != replay.Hash {
+			t.Fatalf("%s block %d is %s, recorded as %s", replay.Kind, replay.Height, header.Hash, replay.Hash)
+		}
+		block, err := zcoinClient.GetBlockByHashWithTransaction(ctx, header.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		registered, err := znodes.Get(ctx, zcoinClient, BlockIdentifier(block))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mapped[replay.Kind], err = Block(ctx, zcoinClient, block, registered, zap.NewNop()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := json.MarshalIndent(mapped, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := ioutil.WriteFile(goldenPath, append(got, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(append(got, '\n')) != string(want) {
+		t.Errorf("mapped blocks differ from %s, run go test -run TestBlockReplay -update -config <mainnet gateway configuration> if the change is intended", goldenPath)
+	}
+}
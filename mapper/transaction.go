@@ -0,0 +1,279 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcutil"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+)
+
+// Currency is the native XZC currency
+var Currency = &types.Currency{
+	Decimals: client.BASE_CURRENCY_DECIMAL_COUNT,
+	Symbol:   client.CURRENCY_SYMBOL,
+}
+
+// Amount converts a zcoind value in XZC into satoshis, negated for debits
+func Amount(value float64, negate bool) (*types.Amount, error) {
+	satoshis, err := btcutil.NewAmount(value)
+	if err != nil {
+		return nil, err
+	}
+	if negate {
+		satoshis = -satoshis
+	}
+
+	return &types.Amount{
+		Value:    fmt.Sprintf("%d", int64(satoshis)),
+		Currency: Currency,
+	}, nil
+}
+
+// TransactionCache holds the transactions already known while mapping a
+// block so that inputs spending them do not need another node round trip
+type TransactionCache map[string]*client.TxRawResult
+
+func (cache TransactionCache) get(ctx context.Context, zcoinClient client.ZcoinClient, hash string) (*client.TxRawResult, error) {
+	if tx, ok := cache[hash]; ok {
+		return tx, nil
+	}
+
+	tx, err := zcoinClient.GetRawTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	cache[hash] = tx
+
+	return tx, nil
+}
+
+// poolAccount returns the pseudo-account of the privacy pool an operation
+// type moves value in or out of
+func poolAccount(opType string) *types.AccountIdentifier {
+	address := client.SIGMA_POOL_ADDRESS
+	if opType == client.LelantusMint || opType == client.LelantusJoinSplit {
+		address = client.LELANTUS_POOL_ADDRESS
+	}
+
+	return &types.AccountIdentifier{
+		Address: address,
+	}
+}
+
+// feeOperation moves value in or out of the fee pool, it has no network index
+// since no input or output carries the fee
+func feeOperation(index int64, value int64) *types.Operation {
+	return &types.Operation{
+		OperationIdentifier: &types.OperationIdentifier{
+			Index: index,
+		},
+		Type:   client.ActionTypeFee,
+		Status: client.StatusSuccess,
+		Account: &types.AccountIdentifier{
+			Address: client.FEE_POOL_ADDRESS,
+		},
+		Amount: &types.Amount{
+			Value:    fmt.Sprintf("%d", value),
+			Currency: Currency,
+		},
+	}
+}
+
+// scriptAccount returns the account owning the value locked by a script
+func scriptAccount(script *btcjson.ScriptPubKeyResult) *types.AccountIdentifier {
+	return &types.AccountIdentifier{
+		Address: client.ScriptAddress(script),
+	}
+}
+
+// joinSplitValue is the value a joinsplit takes out of the lelantus pool, its
+// transparent outputs and fee. Joinsplit mints keep their value in the pool.
+func joinSplitValue(tx *client.TxRawResult, fee float64) (float64, error) {
+	total, err := btcutil.NewAmount(fee)
+	if err != nil {
+		return 0, err
+	}
+	for _, vOut := range tx.Vout {
+		value, err := btcutil.NewAmount(vOut.Value)
+		if err != nil {
+			return 0, err
+		}
+		total += value
+	}
+
+	return total.ToBTC(), nil
+}
+
+// Transaction maps a zcoind transaction into a Rosetta transaction. Inputs are
+// debited from the owner of the output they spend, outputs are credited and
+// OP_RETURN outputs carry their data. Operations are indexed in order, their
+// network index is the vin or vout position and outputs relate to the inputs.
+// Privacy mints credit and privacy spends debit the pool pseudo-accounts so
+// that value entering and leaving the pools is accounted for. The fee is a last
+// operation crediting the fee pool pseudo-account. Znode collaterals and
+// coinbase Znode payouts carry the Znode in their metadata.
+func Transaction(
+	ctx context.Context,
+	zcoinClient client.ZcoinClient,
+	tx *client.TxRawResult,
+	cache TransactionCache,
+	znodes *Znodes,
+	logger *zap.Logger,
+) (*types.Transaction, error) {
+	txOperations := make([]*types.Operation, 0)
+	addOperation := func(
+		opType string,
+		networkIndex int64,
+		account *types.AccountIdentifier,
+		amount *types.Amount,
+	) *types.Operation {
+		operation := &types.Operation{
+			OperationIdentifier: &types.OperationIdentifier{
+				Index:        int64(len(txOperations)),
+				NetworkIndex: &networkIndex,
+			},
+			Type:    opType,
+			Status:  client.StatusSuccess,
+			Account: account,
+			Amount:  amount,
+		}
+		txOperations = append(txOperations, operation)
+
+		return operation
+	}
+
+	coinbase, privacy := false, false
+	for index, vIn := range tx.Vin {
+		networkIndex := int64(index)
+		if vIn.IsCoinBase() {
+			coinbase = true
+			continue
+		}
+
+		privacy = privacy || vIn.IsPrivacySpend()
+		if vIn.IsLelantusJoinSplit() {
+			// nFees is missing when the node could not verify the joinsplit
+			fee := 0.0
+			if vIn.NFees != nil {
+				fee = *vIn.NFees
+			}
+			value, err := joinSplitValue(tx, fee)
+			if err != nil {
+				return nil, err
+			}
+			amount, err := Amount(value, true)
+			if err != nil {
+				return nil, err
+			}
+			addOperation(client.LelantusJoinSplit, networkIndex, poolAccount(client.LelantusJoinSplit), amount)
+			continue
+		}
+
+		if vIn.IsSigmaSpend() {
+			amount, err := Amount(vIn.Value, true)
+			if err != nil {
+				return nil, err
+			}
+			addOperation(client.SigmaSpend, networkIndex, poolAccount(client.SigmaSpend), amount)
+			continue
+		}
+
+		prevTx, err := cache.get(ctx, zcoinClient, vIn.Txid)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to get transaction %s spent by %s", vIn.Txid, tx.Txid)
+		}
+		if int(vIn.Vout) >= len(prevTx.Vout) {
+			return nil, errors.Errorf("transaction %s has no output %d", vIn.Txid, vIn.Vout)
+		}
+
+		prevOut := &prevTx.Vout[vIn.Vout]
+		amount, err := Amount(prevOut.Value, true)
+		if err != nil {
+			return nil, err
+		}
+		operation := addOperation(client.Transfer, networkIndex, scriptAccount(&prevOut.ScriptPubKey), amount)
+		if znode := znodes.Collateral(Outpoint(vIn.Txid, vIn.Vout)); znode != nil {
+			operation.Metadata = map[string]interface{}{
+				"znode": znode,
+			}
+		}
+	}
+
+	// outputs are funded by every input of the transaction
+	inputs := make([]*types.OperationIdentifier, 0, len(txOperations))
+	for _, operation := range txOperations {
+		inputs = append(inputs, operation.OperationIdentifier)
+	}
+	addOutputOperation := func(opType string, index int, account *types.AccountIdentifier, amount *types.Amount) *types.Operation {
+		operation := addOperation(opType, int64(index), account, amount)
+		if len(inputs) > 0 {
+			operation.RelatedOperations = inputs
+		}
+
+		return operation
+	}
+
+	for i := range tx.Vout {
+		vOut := &tx.Vout[i]
+		amount, err := Amount(vOut.Value, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if mintType := client.MintType(vOut); mintType != "" {
+			privacy = true
+			addOutputOperation(mintType, i, poolAccount(mintType), amount)
+			continue
+		}
+		if client.IsNullData(&vOut.ScriptPubKey) {
+			data, err := client.NullDataPayload(&vOut.ScriptPubKey)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to decode output %d of %s", vOut.N, tx.Txid)
+			}
+			operation := addOutputOperation(client.NullData, i, scriptAccount(&vOut.ScriptPubKey), amount)
+			operation.Metadata = map[string]interface{}{
+				"data": data,
+			}
+			continue
+		}
+		operation := addOutputOperation(client.Transfer, i, scriptAccount(&vOut.ScriptPubKey), amount)
+		if znode := znodes.Collateral(Outpoint(tx.Txid, vOut.N)); znode != nil {
+			operation.Metadata = map[string]interface{}{
+				"znode": znode,
+			}
+		} else if coinbase {
+			if payouts := znodes.Payout(operation.Account.Address); len(payouts) > 0 {
+				operation.Metadata = map[string]interface{}{
+					"znodePayouts": payouts,
+				}
+			}
+		}
+	}
+
+	// the fee is credited to the fee pool, the coinbase of the block claims it
+	if fee := operationsFee(txOperations); !coinbase && fee > 0 {
+		operation := feeOperation(int64(len(txOperations)), fee)
+		if len(inputs) > 0 {
+			operation.RelatedOperations = inputs
+		}
+		txOperations = append(txOperations, operation)
+	}
+
+	metadata, err := Metadata(transactionMetadata(ctx, zcoinClient, tx, txOperations, coinbase, privacy, logger))
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{
+			Hash: tx.Txid,
+		},
+		Metadata:   metadata,
+		Operations: txOperations,
+	}, nil
+}
@@ -0,0 +1,137 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+func TestTransactionJoinSplit(t *testing.T) {
+	fee := 0.01
+	tests := []struct {
+		name  string
+		fees  *float64
+		value string
+	}{
+		{name: "with fee", fees: &fee, value: "-501000000"},
+		{name: "without fee", value: "-500000000"},
+	}
+
+	stub := zcoindtest.NewServer(zcoindtest.NewNode())
+	defer stub.Close()
+	zcoinClient := client.NewZcoinClient(stub.Config(), zap.NewNop())
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tx := zcoindtest.Transaction(
+				[]client.Vin{{
+					ScriptSig: &btcjson.ScriptSig{Hex: fmt.Sprintf("%x", client.OP_LELANTUSJOINSPLIT)},
+					NFees:     test.fees,
+				}},
+				zcoindtest.Output(zcoindtest.DefaultMinerAddress, 5),
+				zcoindtest.LelantusJMint(),
+			)
+			tx.Txid = "joinsplit"
+
+			transaction, err := Transaction(context.Background(), zcoinClient, tx, TransactionCache{}, nil, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			spend := transaction.Operations[0]
+			if spend.Type != client.LelantusJoinSplit || spend.Amount.Value != test.value {
+				t.Errorf("got %s %s, want %s %s", spend.Type, spend.Amount.Value, client.LelantusJoinSplit, test.value)
+			}
+		})
+	}
+}
+
+func TestTransactionSizes(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int32
+		vsize   int32
+		weight  int32
+		feeRate float64
+	}{
+		{name: "reported by the node", size: 250, vsize: 200, weight: 800, feeRate: 5},
+		{name: "size only", size: 250, feeRate: 4},
+	}
+
+	stub := zcoindtest.NewServer(zcoindtest.NewNode())
+	defer stub.Close()
+	zcoinClient := client.NewZcoinClient(stub.Config(), zap.NewNop())
+	funding := stub.Node.BlockAt(0).Tx[0]
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tx := zcoindtest.Transaction(
+				[]client.Vin{zcoindtest.Input(&funding, 0)},
+				zcoindtest.Output(zcoindtest.DefaultMinerAddress, zcoindtest.DefaultReward-0.00001),
+			)
+			tx.Txid = "sized"
+			tx.Size, tx.Vsize, tx.Weight = test.size, test.vsize, test.weight
+
+			transaction, err := Transaction(context.Background(), zcoinClient, tx, TransactionCache{}, nil, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			metadata := transaction.Metadata
+			if _, ok := metadata["vsize"]; ok != (test.vsize != 0) {
+				t.Errorf("vsize reported as %v, node reported %d", metadata["vsize"], test.vsize)
+			}
+			if _, ok := metadata["weight"]; ok != (test.weight != 0) {
+				t.Errorf("weight reported as %v, node reported %d", metadata["weight"], test.weight)
+			}
+			if metadata["feeRate"] != test.feeRate {
+				t.Errorf("got fee rate %v, want %v", metadata["feeRate"], test.feeRate)
+			}
+		})
+	}
+}
+
+func TestTransactionSpecialTx(t *testing.T) {
+	tests := []struct {
+		name   string
+		txType int32
+		want   string
+	}{
+		{name: "quorum commitment", txType: client.QUORUM_COMMITMENT, want: "QcTx"},
+		{name: "unknown type", txType: 9, want: "type9"},
+	}
+
+	stub := zcoindtest.NewServer(zcoindtest.NewNode())
+	defer stub.Close()
+	zcoinClient := client.NewZcoinClient(stub.Config(), zap.NewNop())
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tx := zcoindtest.Transaction(nil, zcoindtest.Output(zcoindtest.DefaultMinerAddress, 0))
+			tx.Txid = "special"
+			tx.Type = test.txType
+			tx.ExtraPayload = "00"
+
+			core, logs := observer.New(zap.ErrorLevel)
+			transaction, err := Transaction(context.Background(), zcoinClient, tx, TransactionCache{}, nil, zap.New(core))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the payload is kept raw without being reported as malformed
+			metadata := transaction.Metadata
+			if metadata["type"] != test.want || metadata["extraPayload"] != "00" {
+				t.Errorf("got type %v with payload %v, want %s with 00", metadata["type"], metadata["extraPayload"], test.want)
+			}
+			if logs.Len() != 0 {
+				t.Errorf("logged %v", logs.All())
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package mapper
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/metrics"
+)
+
+type znodeEntry struct {
+	outpoint string
+	info     client.ZnodeInfo
+}
+
+func (entry *znodeEntry) metadata() map[string]interface{} {
+	return map[string]interface{}{
+		"proTxHash":  entry.info.ProTxHash,
+		"status":     entry.info.Status,
+		"collateral": entry.outpoint,
+		"payee":      entry.info.Payee,
+	}
+}
+
+// Znodes indexes the Znode list by collateral outpoint and payout address,
+// a nil Znodes annotates nothing
+type Znodes struct {
+	byCollateral map[string]*znodeEntry
+	byPayee      map[string][]*znodeEntry
+}
+
+// NewZnodes indexes a Znode list keyed by collateral outpoint
+func NewZnodes(list map[string]client.ZnodeInfo) *Znodes {
+	outpoints := make([]string, 0, len(list))
+	for outpoint := range list {
+		outpoints = append(outpoints, outpoint)
+	}
+	sort.Strings(outpoints)
+
+	znodes := &Znodes{
+		byCollateral: make(map[string]*znodeEntry, len(list)),
+		byPayee:      make(map[string][]*znodeEntry, len(list)),
+	}
+	for _, outpoint := range outpoints {
+		entry := &znodeEntry{
+			outpoint: outpoint,
+			info:     list[outpoint],
+		}
+		znodes.byCollateral[outpoint] = entry
+		znodes.byPayee[entry.info.Payee] = append(znodes.byPayee[entry.info.Payee], entry)
+	}
+
+	return znodes
+}
+
+// Outpoint formats an outpoint the way the Znode list keys it
+func Outpoint(txid string, n uint32) string {
+	return fmt.Sprintf("%s-%d", txid, n)
+}
+
+// Collateral returns the metadata of the Znode locking an outpoint
+func (znodes *Znodes) Collateral(outpoint string) map[string]interface{} {
+	if znodes == nil || znodes.byCollateral[outpoint] == nil {
+		return nil
+	}
+
+	return znodes.byCollateral[outpoint].metadata()
+}
+
+// Payout returns the metadata of every Znode paid to an address, ordered by
+// collateral
+func (znodes *Znodes) Payout(address string) []map[string]interface{} {
+	if znodes == nil {
+		return nil
+	}
+
+	result := make([]map[string]interface{}, 0, len(znodes.byPayee[address]))
+	for _, entry := range znodes.byPayee[address] {
+		result = append(result, entry.metadata())
+	}
+
+	return result
+}
+
+// Account returns the metadata of every Znode an address holds the
+// collateral of or is paid to, ordered by collateral
+func (znodes *Znodes) Account(address string) []map[string]interface{} {
+	if znodes == nil {
+		return nil
+	}
+
+	outpoints := make([]string, 0)
+	for outpoint, entry := range znodes.byCollateral {
+		if entry.info.CollateralAddress == address || entry.info.Payee == address {
+			outpoints = append(outpoints, outpoint)
+		}
+	}
+	sort.Strings(outpoints)
+
+	result := make([]map[string]interface{}, 0, len(outpoints))
+	for _, outpoint := range outpoints {
+		result = append(result, znodes.byCollateral[outpoint].metadata())
+	}
+
+	return result
+}
+
+// ErrBlockOrphaned is returned for the Znodes of a block that is no longer on
+// the main chain
+var ErrBlockOrphaned = errors.New("block is no longer on the main chain")
+
+// znodeCacheSize bounds the number of blocks the Znode lists are kept for
+const znodeCacheSize = 64
+
+type cachedZnodes struct {
+	hash   string
+	znodes *Znodes
+}
+
+// znodeFetch is a Znode list lookup in flight, concurrent misses of the same
+// block wait for it instead of calling the node again
+type znodeFetch struct {
+	done   chan struct{}
+	znodes *Znodes
+	err    error
+}
+
+// ZnodeCache keeps the Znode lists of the recently mapped blocks. The Znodes
+// of a block are the ones registered before it, whose collaterals it may
+// spend, and the ones registered by it, so the annotations of a block never
+// depend on the tip it is mapped at.
+type ZnodeCache struct {
+	mu       sync.Mutex
+	entries  *list.List
+	byHash   map[string]*list.Element
+	fetching map[string]*znodeFetch
+}
+
+// NewZnodeCache returns an empty Znode cache
+func NewZnodeCache() *ZnodeCache {
+	return &ZnodeCache{
+		entries:  list.New(),
+		byHash:   make(map[string]*list.Element),
+		fetching: make(map[string]*znodeFetch),
+	}
+}
+
+// Get returns the Znodes of a main chain block. zcoind only lists the Znodes
+// of the main chain, an error is returned once the block has been orphaned.
+// The node is called without holding the lock, a single lookup is made for
+// concurrent misses of the same block.
+func (cache *ZnodeCache) Get(ctx context.Context, zcoinClient client.ZcoinClient, block *types.BlockIdentifier) (*Znodes, error) {
+	cache.mu.Lock()
+	element, ok := cache.byHash[block.Hash]
+	metrics.CacheLookup("znode", ok)
+	if ok {
+		cache.entries.MoveToFront(element)
+		cache.mu.Unlock()
+		return element.Value.(*cachedZnodes).znodes, nil
+	}
+	if fetch, ok := cache.fetching[block.Hash]; ok {
+		cache.mu.Unlock()
+		select {
+		case <-fetch.done:
+			return fetch.znodes, fetch.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	fetch := &znodeFetch{done: make(chan struct{})}
+	cache.fetching[block.Hash] = fetch
+	cache.mu.Unlock()
+
+	fetch.znodes, fetch.err = fetchZnodes(ctx, zcoinClient, block)
+
+	cache.mu.Lock()
+	delete(cache.fetching, block.Hash)
+	if fetch.err == nil {
+		cache.insert(block.Hash, fetch.znodes)
+	}
+	cache.mu.Unlock()
+	close(fetch.done)
+
+	return fetch.znodes, fetch.err
+}
+
+// insert adds the Znodes of a block and evicts the least recently used ones,
+// the caller holds the lock
+func (cache *ZnodeCache) insert(hash string, znodes *Znodes) {
+	if _, ok := cache.byHash[hash]; ok {
+		return
+	}
+	cache.byHash[hash] = cache.entries.PushFront(&cachedZnodes{
+		hash:   hash,
+		znodes: znodes,
+	})
+	if cache.entries.Len() > znodeCacheSize {
+		oldest := cache.entries.Remove(cache.entries.Back()).(*cachedZnodes)
+		delete(cache.byHash, oldest.hash)
+	}
+}
+
+// fetchZnodes lists the Znodes registered before and by a block, and checks
+// that the block is still on the main chain the lists were taken from
+func fetchZnodes(ctx context.Context, zcoinClient client.ZcoinClient, block *types.BlockIdentifier) (*Znodes, error) {
+	registered := make(map[string]client.ZnodeInfo)
+	for _, height := range []int64{block.Index - 1, block.Index} {
+		if height < 0 {
+			continue
+		}
+		listed, err := zcoinClient.GetZnodeList(ctx, height)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to get Znode list at height %d", height)
+		}
+		for outpoint, info := range listed {
+			registered[outpoint] = info
+		}
+	}
+
+	// the lists are the ones of the main chain at the time of the calls
+	current, err := zcoinClient.GetBlock(ctx, block.Index)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get block at height %d", block.Index)
+	}
+	if current.Hash != block.Hash {
+		return nil, errors.Wrapf(ErrBlockOrphaned, "block %s is not at height %d", block.Hash, block.Index)
+	}
+
+	return NewZnodes(registered), nil
+}
@@ -0,0 +1,164 @@
+package mapper
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+func TestZnodesPayout(t *testing.T) {
+	znodes := NewZnodes(map[string]client.ZnodeInfo{
+		"b-1": {ProTxHash: "second", Payee: "payee"},
+		"a-0": {ProTxHash: "first", Payee: "payee"},
+		"c-0": {ProTxHash: "other", Payee: "other"},
+	})
+
+	tests := []struct {
+		address string
+		want    []string
+	}{
+		{address: "payee", want: []string{"first", "second"}},
+		{address: "other", want: []string{"other"}},
+		{address: "unknown"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.address, func(t *testing.T) {
+			payouts := znodes.Payout(test.address)
+			if len(payouts) != len(test.want) {
+				t.Fatalf("got %d payouts, want %d", len(payouts), len(test.want))
+			}
+			for i, payout := range payouts {
+				if payout["proTxHash"] != test.want[i] {
+					t.Errorf("payout %d is %v, want %s", i, payout["proTxHash"], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestZnodeCache(t *testing.T) {
+	node := zcoindtest.NewNode()
+	collateral := node.Mine().Tx[0]
+	node.RegisterZnode(&collateral, 0, client.ZnodeInfo{ProTxHash: "znode", Payee: "payee"})
+	registered := node.Mine()
+	later := node.Mine()
+
+	stub := zcoindtest.NewServer(node)
+	defer stub.Close()
+	zcoinClient := client.NewZcoinClient(stub.Config(), zap.NewNop())
+	outpoint := Outpoint(collateral.Txid, 0)
+
+	tests := []struct {
+		name  string
+		block *client.GetBlockVerboseTxResult
+		want  bool
+	}{
+		{name: "before registration", block: node.BlockAt(1)},
+		{name: "registering block", block: registered, want: true},
+		{name: "later block", block: later, want: true},
+	}
+
+	cache := NewZnodeCache()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			znodes, err := cache.Get(context.Background(), zcoinClient, &types.BlockIdentifier{
+				Index: test.block.Height,
+				Hash:  test.block.Hash,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := znodes.Collateral(outpoint) != nil; got != test.want {
+				t.Errorf("collateral annotated: %t, want %t", got, test.want)
+			}
+		})
+	}
+
+	// the lists of orphaned blocks are no longer available
+	node.Reorg(1)
+	node.Mine()
+	_, err := NewZnodeCache().Get(context.Background(), zcoinClient, &types.BlockIdentifier{
+		Index: later.Height,
+		Hash:  later.Hash,
+	})
+	if errors.Cause(err) != ErrBlockOrphaned {
+		t.Errorf("got %v, want %v", err, ErrBlockOrphaned)
+	}
+}
+
+// blockingClient holds the Znode list calls until released
+type blockingClient struct {
+	client.ZcoinClient
+	calls   int32
+	release chan struct{}
+}
+
+func (c *blockingClient) GetZnodeList(ctx context.Context, height int64) (map[string]client.ZnodeInfo, error) {
+	atomic.AddInt32(&c.calls, 1)
+	<-c.release
+	return c.ZcoinClient.GetZnodeList(ctx, height)
+}
+
+func TestZnodeCacheConcurrentMisses(t *testing.T) {
+	node := zcoindtest.NewNode()
+	first, second := node.Mine(), node.Mine()
+	stub := zcoindtest.NewServer(node)
+	defer stub.Close()
+	blocking := &blockingClient{
+		ZcoinClient: client.NewZcoinClient(stub.Config(), zap.NewNop()),
+		release:     make(chan struct{}),
+	}
+	cache := NewZnodeCache()
+	ctx := context.Background()
+	identifier := &types.BlockIdentifier{Index: second.Height, Hash: second.Hash}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.Get(ctx, blocking, identifier)
+			errs <- err
+		}()
+	}
+	for atomic.LoadInt32(&blocking.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the lock is not held while the node is called
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := cache.Get(cancelled, blocking, identifier); err != context.Canceled {
+		t.Errorf("got %v while the lookup is in flight, want %v", err, context.Canceled)
+	}
+
+	close(blocking.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the lists before and at the block are fetched once
+	if calls := atomic.LoadInt32(&blocking.calls); calls != 2 {
+		t.Errorf("got %d Znode list calls, want 2", calls)
+	}
+
+	if _, err := cache.Get(ctx, blocking, &types.BlockIdentifier{Index: first.Height, Hash: first.Hash}); err != nil {
+		t.Fatal(err)
+	}
+	if calls := atomic.LoadInt32(&blocking.calls); calls != 4 {
+		t.Errorf("got %d Znode list calls after another block, want 4", calls)
+	}
+}
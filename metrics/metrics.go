@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "zcoin_rosetta"
+
+// Cache lookup results
+const (
+	Hit  = "hit"
+	Miss = "miss"
+)
+
+var (
+	// Requests counts the Rosetta requests served per route
+	Requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Requests served per route.",
+	}, []string{"route"})
+
+	// RequestDuration observes the time taken to serve a request per route
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to serve a request per route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route"})
+
+	// RPCDuration observes the time taken by the node to answer per client method
+	RPCDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Time taken by the node to answer per client method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// RPCErrors counts the failed node calls per client method
+	RPCErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Failed node calls per client method.",
+	}, []string{"method"})
+
+	// NodeHeight is the height of the node's best block
+	NodeHeight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_height",
+		Help:      "Height of the node's best block.",
+	})
+
+	// IndexerHeight is the height of the indexed tip, -1 when nothing is indexed
+	IndexerHeight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "indexer_height",
+		Help:      "Height of the indexed tip.",
+	})
+
+	// Reorgs counts the chain reorganizations the indexer unwound
+	Reorgs = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "indexer_reorgs_total",
+		Help:      "Chain reorganizations unwound by the indexer.",
+	})
+
+	// OrphanedBlocks counts the blocks the indexer removed during reorganizations
+	OrphanedBlocks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "indexer_orphaned_blocks_total",
+		Help:      "Blocks removed by the indexer during reorganizations.",
+	})
+
+	// CacheLookups counts the cache lookups per cache and result
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Cache lookups per cache and result.",
+	}, []string{"cache", "result"})
+)
+
+func init() {
+	IndexerHeight.Set(-1)
+}
+
+// CacheLookup records the result of a lookup into the named cache
+func CacheLookup(cache string, hit bool) {
+	result := Miss
+	if hit {
+		result = Hit
+	}
+	CacheLookups.WithLabelValues(cache, result).Inc()
+}
@@ -0,0 +1,13 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestIndexerHeightBeforeIndexing(t *testing.T) {
+	if height := testutil.ToFloat64(IndexerHeight); height != -1 {
+		t.Errorf("got indexer height %v with nothing indexed, want -1", height)
+	}
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type instrumentedRouter struct {
+	router server.Router
+}
+
+// Instrument wraps every route of a router to count its requests and observe
+// their duration, labelled by route name
+func Instrument(router server.Router) server.Router {
+	return &instrumentedRouter{
+		router: router,
+	}
+}
+
+// Routes returns the routes of the wrapped router with instrumented handlers
+func (instrumented *instrumentedRouter) Routes() server.Routes {
+	routes := instrumented.router.Routes()
+	for i := range routes {
+		name, handler := routes[i].Name, routes[i].HandlerFunc
+		routes[i].HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			handler(w, r)
+			Requests.WithLabelValues(name).Inc()
+			RequestDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+		}
+	}
+
+	return routes
+}
+
+// Controller serves the metrics in the Prometheus text format
+type Controller struct {
+	handler http.Handler
+}
+
+// NewController creates the metrics controller
+func NewController() server.Router {
+	return &Controller{
+		handler: promhttp.Handler(),
+	}
+}
+
+// Routes returns all of the api routes for the Controller
+func (c *Controller) Routes() server.Routes {
+	return server.Routes{
+		{
+			Name:        "Metrics",
+			Method:      http.MethodGet,
+			Pattern:     "/metrics",
+			HandlerFunc: c.handler.ServeHTTP,
+		},
+	}
+}
@@ -0,0 +1,132 @@
+package notifier
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/go-zeromq/zmq4"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+const (
+	// TopicHashBlock is published by zcoind with the hash of every new tip
+	TopicHashBlock = "hashblock"
+	// TopicRawTx is published by zcoind with every transaction entering the mempool or a block
+	TopicRawTx = "rawtx"
+
+	reconnectDelay = 5 * time.Second
+)
+
+// Handler receives the notifications published by zcoind
+type Handler interface {
+	// BlockNotification is called with the hash of a new block.
+	BlockNotification(hash string)
+
+	// TransactionNotification is called with the hash and serialization of a new transaction.
+	TransactionNotification(hash string, raw []byte)
+}
+
+// ZMQNotifier subscribes to the zcoind ZMQ publishers and forwards every
+// notification to its handler
+type ZMQNotifier struct {
+	config  configuration.ZMQ
+	handler Handler
+	logger  *zap.Logger
+}
+
+// NewZMQNotifier creates a notifier for the configured ZMQ endpoints
+func NewZMQNotifier(config configuration.ZMQ, handler Handler, logger *zap.Logger) *ZMQNotifier {
+	return &ZMQNotifier{
+		config:  config,
+		handler: handler,
+		logger:  logger,
+	}
+}
+
+// Start subscribes to every configured topic and blocks until the context is
+// cancelled, reconnecting whenever a publisher goes away
+func (notifier *ZMQNotifier) Start(ctx context.Context) error {
+	// zcoind may publish both topics on the same endpoint
+	endpoints := make(map[string][]string)
+	if notifier.config.HashBlock != "" {
+		endpoints[notifier.config.HashBlock] = append(endpoints[notifier.config.HashBlock], TopicHashBlock)
+	}
+	if notifier.config.RawTx != "" {
+		endpoints[notifier.config.RawTx] = append(endpoints[notifier.config.RawTx], TopicRawTx)
+	}
+
+	done := make(chan struct{}, len(endpoints))
+	for endpoint, topics := range endpoints {
+		go func(endpoint string, topics []string) {
+			notifier.subscribe(ctx, endpoint, topics)
+			done <- struct{}{}
+		}(endpoint, topics)
+	}
+
+	for range endpoints {
+		<-done
+	}
+
+	return ctx.Err()
+}
+
+func (notifier *ZMQNotifier) subscribe(ctx context.Context, endpoint string, topics []string) {
+	for {
+		if err := notifier.receive(ctx, endpoint, topics); err != nil && ctx.Err() == nil {
+			notifier.logger.Error("subscription failed, reconnecting",
+				zap.String("endpoint", endpoint),
+				zap.Duration("delay", reconnectDelay),
+				zap.Error(err),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (notifier *ZMQNotifier) receive(ctx context.Context, endpoint string, topics []string) error {
+	socket := zmq4.NewSub(ctx)
+	defer socket.Close()
+
+	if err := socket.Dial(endpoint); err != nil {
+		return err
+	}
+	for _, topic := range topics {
+		if err := socket.SetOption(zmq4.OptionSubscribe, topic); err != nil {
+			return err
+		}
+	}
+
+	for {
+		msg, err := socket.Recv()
+		if err != nil {
+			return err
+		}
+		notifier.dispatch(msg.Frames)
+	}
+}
+
+// dispatch handles a [topic, body, sequence] multipart message
+func (notifier *ZMQNotifier) dispatch(frames [][]byte) {
+	if len(frames) < 2 {
+		return
+	}
+
+	body := frames[1]
+	switch string(frames[0]) {
+	case TopicHashBlock:
+		if len(body) != chainhash.HashSize {
+			return
+		}
+		notifier.handler.BlockNotification(hex.EncodeToString(body))
+	case TopicRawTx:
+		notifier.handler.TransactionNotification(chainhash.DoubleHashH(body).String(), body)
+	}
+}
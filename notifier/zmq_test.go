@@ -0,0 +1,123 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+type recordingHandler struct {
+	mu     sync.Mutex
+	blocks []string
+	txs    []string
+}
+
+func (handler *recordingHandler) BlockNotification(hash string) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	handler.blocks = append(handler.blocks, hash)
+}
+
+func (handler *recordingHandler) TransactionNotification(hash string, raw []byte) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	handler.txs = append(handler.txs, hash)
+}
+
+func (handler *recordingHandler) received() ([]string, []string) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	return append([]string(nil), handler.blocks...), append([]string(nil), handler.txs...)
+}
+
+// waitFor polls until the condition holds or the deadline passes
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(10 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for notifications")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestZMQNotifier(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	publisher, err := zcoindtest.NewPublisher(ctx, "tcp://127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer publisher.Close()
+
+	node := zcoindtest.NewNode()
+	node.SetPublisher(publisher)
+
+	handler := &recordingHandler{}
+	notifier := NewZMQNotifier(configuration.ZMQ{
+		HashBlock: publisher.Endpoint(),
+		RawTx:     publisher.Endpoint(),
+	}, handler, zap.NewNop())
+	done := make(chan error, 1)
+	go func() {
+		done <- notifier.Start(ctx)
+	}()
+
+	// messages published before the subscription is in place are dropped
+	waitFor(t, func() bool {
+		node.Mine()
+		blocks, _ := handler.received()
+		return len(blocks) > 0
+	})
+
+	prevHash := chainhash.DoubleHashH([]byte("funding"))
+	msgTx := wire.NewMsgTx(1)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e8, []byte{0x51}))
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	raw := buf.Bytes()
+	param, _ := json.Marshal(hex.EncodeToString(raw))
+	if _, rpcErr := node.Call("sendrawtransaction", []json.RawMessage{param}); rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+	block := node.Mine()
+
+	waitFor(t, func() bool {
+		blocks, _ := handler.received()
+		return blocks[len(blocks)-1] == block.Hash
+	})
+
+	// relayed once when accepted and once more when mined
+	_, txs := handler.received()
+	relayed := 0
+	for _, hash := range txs {
+		if hash == msgTx.TxHash().String() {
+			relayed++
+		}
+	}
+	if relayed != 2 {
+		t.Errorf("transaction relayed %d times, want 2", relayed)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Start returned %v, want %v", err, context.Canceled)
+	}
+}
@@ -22,5 +22,20 @@ func ProvideDatabase(options badger.Options) (*BadgerDB, error) {
 	}, nil
 }
 
+// Update runs fn inside a read-write transaction.
+func (b *BadgerDB) Update(fn func(txn *badger.Txn) error) error {
+	return b.db.Update(fn)
+}
+
+// View runs fn inside a read-only transaction.
+func (b *BadgerDB) View(fn func(txn *badger.Txn) error) error {
+	return b.db.View(fn)
+}
+
+// Close flushes and closes the underlying database.
+func (b *BadgerDB) Close() error {
+	return b.db.Close()
+}
+
 // DatabaseSet is providing you with a set of database providers
 var DatabaseSet = wire.NewSet(ProvideDatabase)
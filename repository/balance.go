@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	badger "github.com/dgraph-io/badger"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+)
+
+const balancePrefix = "balance/"
+
+func balanceKey(address string, index int64, currency *types.Currency) []byte {
+	return []byte(fmt.Sprintf("%s%s/%020d/%s", balancePrefix, address, index, currency.Symbol))
+}
+
+type accountCurrency struct {
+	address string
+	symbol  string
+}
+
+// balanceDeltas sums the successful operations of a block per account and currency
+func balanceDeltas(transactions []*types.Transaction) (map[accountCurrency]*types.Amount, error) {
+	deltas := make(map[accountCurrency]*types.Amount)
+	for _, transaction := range transactions {
+		for _, op := range transaction.Operations {
+			if op.Account == nil || op.Amount == nil || op.Status != client.StatusSuccess {
+				continue
+			}
+
+			key := accountCurrency{address: op.Account.Address, symbol: op.Amount.Currency.Symbol}
+			delta, ok := deltas[key]
+			if !ok {
+				delta = &types.Amount{Value: "0", Currency: op.Amount.Currency}
+				deltas[key] = delta
+			}
+			value, err := types.AddValues(delta.Value, op.Amount.Value)
+			if err != nil {
+				return nil, err
+			}
+			delta.Value = value
+		}
+	}
+
+	return deltas, nil
+}
+
+// storeBalanceDeltas records how much the balance of every account changed in
+// the block, a historical balance is the sum of the deltas up to its height
+func storeBalanceDeltas(txn *badger.Txn, index int64, transactions []*types.Transaction) error {
+	deltas, err := balanceDeltas(transactions)
+	if err != nil {
+		return err
+	}
+
+	for key, delta := range deltas {
+		if err := setJSON(txn, balanceKey(key.address, index, delta.Currency), delta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetBalances replays the balance deltas of an account up to the given height,
+// the balances are sorted by currency symbol
+func (b *BlockProvider) GetBalances(address string, index int64) ([]*types.Amount, error) {
+	prefix := []byte(balancePrefix + address + "/")
+	balances := make(map[string]*types.Amount)
+
+	err := b.badgerDb.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			key := string(it.Item().Key()[len(prefix):])
+			height, err := strconv.ParseInt(key[:strings.Index(key, "/")], 10, 64)
+			if err != nil {
+				return err
+			}
+			if height > index {
+				break
+			}
+
+			delta := &types.Amount{}
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, delta)
+			}); err != nil {
+				return err
+			}
+
+			balance, ok := balances[delta.Currency.Symbol]
+			if !ok {
+				balances[delta.Currency.Symbol] = delta
+				continue
+			}
+			if balance.Value, err = types.AddValues(balance.Value, delta.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make([]*types.Amount, 0, len(balances))
+	for _, balance := range balances {
+		amounts = append(amounts, balance)
+	}
+	sort.Slice(amounts, func(i, j int) bool {
+		return amounts[i].Currency.Symbol < amounts[j].Currency.Symbol
+	})
+
+	return amounts, nil
+}
@@ -2,16 +2,37 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
+	badger "github.com/dgraph-io/badger"
+	"github.com/pkg/errors"
 	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/dependency"
 	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/provider"
 )
 
+const (
+	blockHashPrefix  = "block/hash/"
+	blockIndexPrefix = "block/index/"
+	tipKey           = "block/tip"
+)
+
+// ErrNotFound is returned when the requested entry has not been indexed
+var ErrNotFound = errors.New("not found")
+
+// BlockProvider persists the indexed blocks inside Badger
 type BlockProvider struct {
 	badgerDb *provider.BadgerDB
 }
 
+// NewBlockProvider creates a block repository on top of the given database
+func NewBlockProvider(badgerDb *provider.BadgerDB) *BlockProvider {
+	return &BlockProvider{
+		badgerDb: badgerDb,
+	}
+}
+
 func initializeDatabase(ctx context.Context) (*BlockProvider, error) {
 	badgerdb, _ := dependency.InitBadgerDb()
 	b := &BlockProvider{
@@ -20,6 +41,134 @@ func initializeDatabase(ctx context.Context) (*BlockProvider, error) {
 	return b, nil
 }
 
-func (b *BlockProvider) StoreBlock(keyHash string, block *types.BlockResponse) {
+func blockHashKey(hash string) []byte {
+	return []byte(blockHashPrefix + hash)
+}
+
+func blockIndexKey(index int64) []byte {
+	return []byte(fmt.Sprintf("%s%020d", blockIndexPrefix, index))
+}
+
+func getJSON(txn *badger.Txn, key []byte, value interface{}) error {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return item.Value(func(val []byte) error {
+		return json.Unmarshal(val, value)
+	})
+}
+
+func setJSON(txn *badger.Txn, key []byte, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return txn.Set(key, encoded)
+}
+
+// StoreBlock persists the block along with its transactions and marks it as
+// the indexed tip, recording the addition in the block event log
+func (b *BlockProvider) StoreBlock(keyHash string, block *types.BlockResponse, transactions []*types.Transaction) error {
+	return b.badgerDb.Update(func(txn *badger.Txn) error {
+		if err := setJSON(txn, blockHashKey(keyHash), block); err != nil {
+			return err
+		}
+		if err := storeTransactions(txn, block.Block.BlockIdentifier.Index, transactions); err != nil {
+			return err
+		}
+		if err := storeBalanceDeltas(txn, block.Block.BlockIdentifier.Index, transactions); err != nil {
+			return err
+		}
+		if err := txn.Set(blockIndexKey(block.Block.BlockIdentifier.Index), []byte(keyHash)); err != nil {
+			return err
+		}
+		if err := appendBlockEvent(txn, BlockAdded, block.Block.BlockIdentifier); err != nil {
+			return err
+		}
+		return setJSON(txn, []byte(tipKey), block.Block.BlockIdentifier)
+	})
+}
+
+// RemoveBlock deletes the indexed tip with its transactions and moves the tip
+// back to its parent, recording the removal in the block event log
+func (b *BlockProvider) RemoveBlock(keyHash string) error {
+	return b.badgerDb.Update(func(txn *badger.Txn) error {
+		block := &types.BlockResponse{}
+		if err := getJSON(txn, blockHashKey(keyHash), block); err != nil {
+			return err
+		}
+		if err := txn.Delete(blockHashKey(keyHash)); err != nil {
+			return err
+		}
+		if err := txn.Delete(blockIndexKey(block.Block.BlockIdentifier.Index)); err != nil {
+			return err
+		}
+		if err := removeTransactions(txn, block.Block.BlockIdentifier.Index); err != nil {
+			return err
+		}
+		if err := appendBlockEvent(txn, BlockRemoved, block.Block.BlockIdentifier); err != nil {
+			return err
+		}
+		if block.Block.BlockIdentifier.Index == 0 {
+			return txn.Delete([]byte(tipKey))
+		}
+		return setJSON(txn, []byte(tipKey), block.Block.ParentBlockIdentifier)
+	})
+}
+
+// GetBlockByHash returns the indexed block with the given hash
+func (b *BlockProvider) GetBlockByHash(keyHash string) (*types.BlockResponse, error) {
+	block := &types.BlockResponse{}
+	err := b.badgerDb.View(func(txn *badger.Txn) error {
+		return getJSON(txn, blockHashKey(keyHash), block)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+// GetBlockByIndex returns the indexed block at the given height
+func (b *BlockProvider) GetBlockByIndex(index int64) (*types.BlockResponse, error) {
+	block := &types.BlockResponse{}
+	err := b.badgerDb.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(blockIndexKey(index))
+		if err == badger.ErrKeyNotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		hash, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		return getJSON(txn, blockHashKey(string(hash)), block)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+// GetTip returns the identifier of the latest indexed block or ErrNotFound
+// when nothing has been indexed yet
+func (b *BlockProvider) GetTip() (*types.BlockIdentifier, error) {
+	tip := &types.BlockIdentifier{}
+	err := b.badgerDb.View(func(txn *badger.Txn) error {
+		return getJSON(txn, []byte(tipKey), tip)
+	})
+	if err != nil {
+		return nil, err
+	}
 
+	return tip, nil
 }
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+var (
+	xzc   = &types.Currency{Symbol: "XZC", Decimals: 8}
+	token = &types.Currency{Symbol: "ELYSIUM-3", Decimals: 8}
+)
+
+func operation(index int64, opType string, address string, value string, currency *types.Currency) *types.Operation {
+	return &types.Operation{
+		OperationIdentifier: &types.OperationIdentifier{Index: index},
+		Type:                opType,
+		Status:              client.StatusSuccess,
+		Account:             &types.AccountIdentifier{Address: address},
+		Amount:              &types.Amount{Value: value, Currency: currency},
+	}
+}
+
+// transfer moves value from one address to another, tokens with Elysium sends
+func transfer(txid string, from string, to string, value int64, currency *types.Currency) *types.Transaction {
+	opType := client.Transfer
+	if currency != xzc {
+		opType = client.ElysiumSend
+	}
+	return &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: txid},
+		Operations: []*types.Operation{
+			operation(0, opType, from, fmt.Sprintf("-%d", value), currency),
+			operation(1, opType, to, fmt.Sprintf("%d", value), currency),
+		},
+	}
+}
+
+// storeBlock stores a block on top of parent, nil for the genesis block
+func storeBlock(t *testing.T, blocks *BlockProvider, parent *types.BlockIdentifier, hash string, transactions ...*types.Transaction) *types.BlockIdentifier {
+	identifier := &types.BlockIdentifier{Hash: hash}
+	if parent != nil {
+		identifier.Index = parent.Index + 1
+	} else {
+		parent = identifier
+	}
+
+	err := blocks.StoreBlock(hash, &types.BlockResponse{
+		Block: &types.Block{
+			BlockIdentifier:       identifier,
+			ParentBlockIdentifier: parent,
+		},
+	}, transactions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identifier
+}
+
+func newBlocks(t *testing.T) (*BlockProvider, func()) {
+	db, err := zcoindtest.NewDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewBlockProvider(db.BadgerDB), func() { db.Close() }
+}
+
+func TestBlockProviderReorg(t *testing.T) {
+	blocks, closeDB := newBlocks(t)
+	defer closeDB()
+
+	if _, err := blocks.GetTip(); err != ErrNotFound {
+		t.Fatalf("got %v for the tip of an empty index, want %v", err, ErrNotFound)
+	}
+
+	genesis := storeBlock(t, blocks, nil, "genesis", transfer("mint", "coinbase", "alice", 100, xzc))
+	first := storeBlock(t, blocks, genesis, "first", transfer("pay", "alice", "bob", 30, xzc))
+	storeBlock(t, blocks, first, "orphan", transfer("spend", "bob", "carol", 10, xzc))
+	if err := blocks.RemoveBlock("orphan"); err != nil {
+		t.Fatal(err)
+	}
+	tip := storeBlock(t, blocks, first, "second", transfer("send", "alice", "bob", 5, token))
+
+	got, err := blocks.GetTip()
+	if err != nil || !reflect.DeepEqual(got, tip) {
+		t.Fatalf("got tip %+v (%v), want %+v", got, err, tip)
+	}
+	if _, err := blocks.GetBlockByHash("orphan"); err != ErrNotFound {
+		t.Errorf("got %v for the orphaned block, want %v", err, ErrNotFound)
+	}
+	if block, err := blocks.GetBlockByIndex(2); err != nil || block.Block.BlockIdentifier.Hash != "second" {
+		t.Errorf("got %+v (%v) at height 2, want the second block", block, err)
+	}
+
+	tests := []struct {
+		name    string
+		address string
+		index   int64
+		want    []*types.Amount
+	}{
+		{"before the payment", "bob", 0, []*types.Amount{}},
+		{"after the payment", "bob", 1, []*types.Amount{{Value: "30", Currency: xzc}}},
+		{"orphaned spend is gone", "carol", 2, []*types.Amount{}},
+		{"tokens sort first", "bob", 2, []*types.Amount{{Value: "5", Currency: token}, {Value: "30", Currency: xzc}}},
+		{"every delta summed", "alice", 2, []*types.Amount{{Value: "-5", Currency: token}, {Value: "70", Currency: xzc}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := blocks.GetBalances(test.address, test.index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", types.PrettyPrintStruct(got), types.PrettyPrintStruct(test.want))
+			}
+		})
+	}
+}
+
+func TestBlockEvents(t *testing.T) {
+	blocks, closeDB := newBlocks(t)
+	defer closeDB()
+
+	if events, last, err := blocks.GetBlockEvents(0, 10); err != nil || len(events) != 0 || last != -1 {
+		t.Fatalf("got %v up to %d (%v) from an empty log", events, last, err)
+	}
+
+	genesis := storeBlock(t, blocks, nil, "genesis")
+	first := storeBlock(t, blocks, genesis, "first")
+	if err := blocks.RemoveBlock("first"); err != nil {
+		t.Fatal(err)
+	}
+	storeBlock(t, blocks, genesis, "replacement")
+
+	tests := []struct {
+		name   string
+		offset int64
+		limit  int
+		want   []*BlockEvent
+	}{
+		{
+			name:   "from the start",
+			offset: 0,
+			limit:  2,
+			want: []*BlockEvent{
+				{Sequence: 0, BlockIdentifier: genesis, Type: BlockAdded},
+				{Sequence: 1, BlockIdentifier: first, Type: BlockAdded},
+			},
+		},
+		{
+			name:   "removal in between",
+			offset: 2,
+			limit:  10,
+			want: []*BlockEvent{
+				{Sequence: 2, BlockIdentifier: first, Type: BlockRemoved},
+				{Sequence: 3, BlockIdentifier: &types.BlockIdentifier{Index: 1, Hash: "replacement"}, Type: BlockAdded},
+			},
+		},
+		{
+			name:   "past the end",
+			offset: 4,
+			limit:  10,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events, last, err := blocks.GetBlockEvents(test.offset, test.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if last != 3 {
+				t.Errorf("got last sequence %d, want 3", last)
+			}
+			if !reflect.DeepEqual(events, test.want) {
+				t.Errorf("got %v, want %v", types.PrettyPrintStruct(events), types.PrettyPrintStruct(test.want))
+			}
+		})
+	}
+}
+
+func TestSearchTransactions(t *testing.T) {
+	blocks, closeDB := newBlocks(t)
+	defer closeDB()
+
+	genesis := storeBlock(t, blocks, nil, "genesis", transfer("a", "miner", "alice", 100, xzc))
+	first := storeBlock(t, blocks, genesis, "first",
+		transfer("b", "alice", "bob", 30, xzc),
+		transfer("c", "alice", "bob", 5, token),
+	)
+	storeBlock(t, blocks, first, "second", transfer("d", "bob", "carol", 10, xzc))
+	one := int64(1)
+
+	tests := []struct {
+		name   string
+		filter *TransactionFilter
+		limit  int
+		want   []string
+	}{
+		{"every transaction", &TransactionFilter{}, 2, []string{"a", "b", "c", "d"}},
+		{"by address in chain order", &TransactionFilter{Address: "bob"}, 1, []string{"b", "c", "d"}},
+		{"by address and currency", &TransactionFilter{Address: "bob", Currency: token}, 10, []string{"c"}},
+		{"by type", &TransactionFilter{Type: client.Transfer, MinBlock: &one}, 10, []string{"b", "d"}},
+		{"within blocks", &TransactionFilter{MinBlock: &one, MaxBlock: &one}, 10, []string{"b", "c"}},
+		{"no match", &TransactionFilter{Address: "dave"}, 10, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			cursor := ""
+			for {
+				found, next, err := blocks.SearchTransactions(test.filter, cursor, test.limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(found) > test.limit {
+					t.Fatalf("got %d transactions, limit is %d", len(found), test.limit)
+				}
+				for _, transaction := range found {
+					got = append(got, transaction.Transaction.TransactionIdentifier.Hash)
+				}
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if _, _, err := blocks.SearchTransactions(&TransactionFilter{}, "garbage", 10); err == nil {
+		t.Error("searched from a cursor no search returned")
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	badger "github.com/dgraph-io/badger"
+)
+
+// Types of block events
+const (
+	BlockAdded   = "block_added"
+	BlockRemoved = "block_removed"
+)
+
+const (
+	eventPrefix      = "event/block/"
+	eventSequenceKey = "event/sequence"
+)
+
+// BlockEvent records a block being added to or removed from the indexed
+// chain, sequences start at 0 and never have gaps
+type BlockEvent struct {
+	Sequence        int64                  `json:"sequence"`
+	BlockIdentifier *types.BlockIdentifier `json:"block_identifier"`
+	Type            string                 `json:"type"`
+}
+
+func eventKey(sequence int64) []byte {
+	return []byte(fmt.Sprintf("%s%020d", eventPrefix, sequence))
+}
+
+// nextSequence returns the sequence the next event will get
+func nextSequence(txn *badger.Txn) (int64, error) {
+	var next int64
+	err := getJSON(txn, []byte(eventSequenceKey), &next)
+	if err == ErrNotFound {
+		return 0, nil
+	}
+
+	return next, err
+}
+
+// appendBlockEvent adds an event to the log, it is written in the same
+// transaction as the change it records so the log never misses one
+func appendBlockEvent(txn *badger.Txn, eventType string, block *types.BlockIdentifier) error {
+	sequence, err := nextSequence(txn)
+	if err != nil {
+		return err
+	}
+
+	event := &BlockEvent{
+		Sequence:        sequence,
+		BlockIdentifier: block,
+		Type:            eventType,
+	}
+	if err := setJSON(txn, eventKey(sequence), event); err != nil {
+		return err
+	}
+
+	return setJSON(txn, []byte(eventSequenceKey), sequence+1)
+}
+
+// GetBlockEvents returns up to limit events starting at the offset sequence,
+// along with the sequence of the latest event or -1 when there is none
+func (b *BlockProvider) GetBlockEvents(offset int64, limit int) ([]*BlockEvent, int64, error) {
+	var events []*BlockEvent
+	var next int64
+
+	err := b.badgerDb.View(func(txn *badger.Txn) error {
+		var err error
+		if next, err = nextSequence(txn); err != nil {
+			return err
+		}
+
+		for sequence := offset; sequence < next && len(events) < limit; sequence++ {
+			event := &BlockEvent{}
+			if err := getJSON(txn, eventKey(sequence), event); err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, next - 1, nil
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	badger "github.com/dgraph-io/badger"
+	"github.com/pkg/errors"
+)
+
+const (
+	transactionPrefix = "transaction/"
+	addressPrefix     = "address/"
+)
+
+// ErrInvalidCursor is returned when a search cursor was not produced by a
+// previous search
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// BlockTransaction is an indexed transaction along with the block including it
+type BlockTransaction struct {
+	BlockIdentifier *types.BlockIdentifier `json:"block_identifier"`
+	Transaction     *types.Transaction     `json:"transaction"`
+}
+
+// TransactionFilter selects the transactions returned by a search, a
+// transaction matches when one of its operations satisfies every criterion
+type TransactionFilter struct {
+	Address  string
+	Currency *types.Currency
+	Type     string
+	Status   string
+	MinBlock *int64
+	MaxBlock *int64
+}
+
+func (filter *TransactionFilter) matches(op *types.Operation) bool {
+	if filter.Address != "" && (op.Account == nil || op.Account.Address != filter.Address) {
+		return false
+	}
+	if filter.Currency != nil && (op.Amount == nil || !sameCurrency(op.Amount.Currency, filter.Currency)) {
+		return false
+	}
+	if filter.Type != "" && op.Type != filter.Type {
+		return false
+	}
+	return filter.Status == "" || op.Status == filter.Status
+}
+
+func sameCurrency(a *types.Currency, b *types.Currency) bool {
+	return a != nil && a.Symbol == b.Symbol && a.Decimals == b.Decimals
+}
+
+// position identifies a transaction in the chain, positions sort in chain order
+func position(index int64, txid string) string {
+	return fmt.Sprintf("%020d/%s", index, txid)
+}
+
+func parsePosition(position string) (int64, string, error) {
+	parts := strings.SplitN(position, "/", 2)
+	if len(parts) != 2 || len(parts[0]) != 20 || parts[1] == "" {
+		return 0, "", ErrInvalidCursor
+	}
+	index, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+
+	return index, parts[1], nil
+}
+
+func transactionKey(index int64, txid string) []byte {
+	return []byte(transactionPrefix + position(index, txid))
+}
+
+func addressKey(address string, index int64, txid string, opIndex int64) []byte {
+	return []byte(fmt.Sprintf("%s%s/%s/%010d", addressPrefix, address, position(index, txid), opIndex))
+}
+
+// storeTransactions indexes the transactions of a block and the operations
+// touching each account, keyed by address, height, txid and operation index
+func storeTransactions(txn *badger.Txn, index int64, transactions []*types.Transaction) error {
+	for _, transaction := range transactions {
+		txid := transaction.TransactionIdentifier.Hash
+		if err := setJSON(txn, transactionKey(index, txid), transaction); err != nil {
+			return err
+		}
+		for _, op := range transaction.Operations {
+			if op.Account == nil {
+				continue
+			}
+			if err := setJSON(txn, addressKey(op.Account.Address, index, txid, op.OperationIdentifier.Index), op); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeTransactions deletes everything storeTransactions and
+// storeBalanceDeltas indexed for a block
+func removeTransactions(txn *badger.Txn, index int64) error {
+	prefix := []byte(fmt.Sprintf("%s%020d/", transactionPrefix, index))
+	var keys [][]byte
+
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		transaction := &types.Transaction{}
+		err := it.Item().Value(func(val []byte) error {
+			return json.Unmarshal(val, transaction)
+		})
+		if err != nil {
+			it.Close()
+			return err
+		}
+
+		txid := transaction.TransactionIdentifier.Hash
+		keys = append(keys, it.Item().KeyCopy(nil))
+		for _, op := range transaction.Operations {
+			if op.Account == nil {
+				continue
+			}
+			keys = append(keys, addressKey(op.Account.Address, index, txid, op.OperationIdentifier.Index))
+			if op.Amount != nil {
+				keys = append(keys, balanceKey(op.Account.Address, index, op.Amount.Currency))
+			}
+		}
+	}
+	it.Close()
+
+	for _, key := range keys {
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetTransactions returns the indexed transactions of the block at the given
+// height, ordered by hash
+func (b *BlockProvider) GetTransactions(index int64) ([]*types.Transaction, error) {
+	prefix := []byte(fmt.Sprintf("%s%020d/", transactionPrefix, index))
+	var transactions []*types.Transaction
+
+	err := b.badgerDb.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			transaction := &types.Transaction{}
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, transaction)
+			})
+			if err != nil {
+				return err
+			}
+			transactions = append(transactions, transaction)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// SearchTransactions returns up to limit transactions matching the filter in
+// chain order, starting after the cursor. The returned cursor is empty once
+// there are no more matches.
+func (b *BlockProvider) SearchTransactions(filter *TransactionFilter, cursor string, limit int) ([]*BlockTransaction, string, error) {
+	if cursor != "" {
+		if _, _, err := parsePosition(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// with an address the account index is scanned, otherwise every transaction
+	prefix := transactionPrefix
+	if filter.Address != "" {
+		prefix = addressPrefix + filter.Address + "/"
+	}
+	start := prefix
+	if cursor != "" {
+		start += cursor
+	} else if filter.MinBlock != nil {
+		start += fmt.Sprintf("%020d", *filter.MinBlock)
+	}
+
+	var transactions []*BlockTransaction
+	next := ""
+	err := b.badgerDb.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		seen := cursor
+		for it.Seek([]byte(start)); it.ValidForPrefix([]byte(prefix)); it.Next() {
+			// account entries carry the operation index after the position
+			key := string(it.Item().Key()[len(prefix):])
+			if filter.Address != "" {
+				key = key[:strings.LastIndex(key, "/")]
+			}
+			if key <= seen {
+				continue
+			}
+			seen = key
+			index, txid, err := parsePosition(key)
+			if err != nil {
+				return err
+			}
+			if filter.MaxBlock != nil && index > *filter.MaxBlock {
+				break
+			}
+
+			transaction := &types.Transaction{}
+			if err := getJSON(txn, transactionKey(index, txid), transaction); err != nil {
+				return err
+			}
+			if !matchesAny(filter, transaction) {
+				continue
+			}
+			if len(transactions) == limit {
+				last := transactions[limit-1]
+				next = position(last.BlockIdentifier.Index, last.Transaction.TransactionIdentifier.Hash)
+				break
+			}
+
+			hash, err := txn.Get(blockIndexKey(index))
+			if err != nil {
+				return err
+			}
+			blockHash, err := hash.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			transactions = append(transactions, &BlockTransaction{
+				BlockIdentifier: &types.BlockIdentifier{Index: index, Hash: string(blockHash)},
+				Transaction:     transaction,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return transactions, next, nil
+}
+
+func matchesAny(filter *TransactionFilter, transaction *types.Transaction) bool {
+	for _, op := range transaction.Operations {
+		if filter.matches(op) {
+			return true
+		}
+	}
+
+	return false
+}
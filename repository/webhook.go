@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	badger "github.com/dgraph-io/badger"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/provider"
+)
+
+const (
+	watchPrefix         = "webhook/watch/"
+	watchAddressPrefix  = "webhook/address/"
+	depositPrefix       = "webhook/deposit/"
+	duePrefix           = "webhook/due/"
+	deliveryPrefix      = "webhook/delivery/"
+	deliverySequenceKey = "webhook/sequence"
+)
+
+// Watch registers a webhook called for the deposits to an address once they
+// reach the given number of confirmations
+type Watch struct {
+	ID            string `json:"id"`
+	Address       string `json:"address"`
+	URL           string `json:"url"`
+	Secret        string `json:"secret,omitempty"`
+	Confirmations int64  `json:"confirmations"`
+}
+
+// Deposit is an operation crediting a watched address, it is tracked until it
+// has been confirmed for a while in case its block gets orphaned. Due is the
+// tip height at which the deposit has to be looked at again.
+type Deposit struct {
+	WatchID               string                       `json:"watch_id"`
+	BlockIdentifier       *types.BlockIdentifier       `json:"block_identifier"`
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+	OperationIdentifier   *types.OperationIdentifier   `json:"operation_identifier"`
+	Amount                *types.Amount                `json:"amount"`
+	Confirmed             bool                         `json:"confirmed"`
+	Due                   int64                        `json:"due"`
+}
+
+// Delivery is a webhook call waiting to succeed
+type Delivery struct {
+	ID          string          `json:"id"`
+	WatchID     string          `json:"watch_id"`
+	Event       string          `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"next_attempt"`
+}
+
+// WebhookProvider persists the webhook watches, the deposits they track and
+// the pending deliveries inside Badger
+type WebhookProvider struct {
+	badgerDb *provider.BadgerDB
+}
+
+// NewWebhookProvider creates a webhook repository on top of the given database
+func NewWebhookProvider(badgerDb *provider.BadgerDB) *WebhookProvider {
+	return &WebhookProvider{
+		badgerDb: badgerDb,
+	}
+}
+
+func watchKey(id string) []byte {
+	return []byte(watchPrefix + id)
+}
+
+func watchAddressKey(address string, id string) []byte {
+	return []byte(watchAddressPrefix + address + "/" + id)
+}
+
+func depositKey(deposit *Deposit) []byte {
+	return []byte(fmt.Sprintf(
+		"%s%020d/%s/%s/%010d",
+		depositPrefix,
+		deposit.BlockIdentifier.Index,
+		deposit.WatchID,
+		deposit.TransactionIdentifier.Hash,
+		deposit.OperationIdentifier.Index,
+	))
+}
+
+// dueKey indexes a deposit by due height, the deposit key is its value
+func dueKey(deposit *Deposit) []byte {
+	return []byte(fmt.Sprintf("%s%020d/%s", duePrefix, deposit.Due, depositKey(deposit)[len(depositPrefix):]))
+}
+
+// setDeposit writes a deposit along with its due index entry
+func setDeposit(txn *badger.Txn, deposit *Deposit) error {
+	if err := setJSON(txn, depositKey(deposit), deposit); err != nil {
+		return err
+	}
+
+	return txn.Set(dueKey(deposit), depositKey(deposit))
+}
+
+func deliveryKey(id string) []byte {
+	return []byte(deliveryPrefix + id)
+}
+
+// scanJSON decodes every value under the prefix, newValue allocates the value
+// each entry is decoded into
+func scanJSON(txn *badger.Txn, prefix []byte, newValue func() interface{}) error {
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		value := newValue()
+		err := it.Item().Value(func(val []byte) error {
+			return json.Unmarshal(val, value)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddWatch persists a watch and indexes it by address
+func (w *WebhookProvider) AddWatch(watch *Watch) error {
+	return w.badgerDb.Update(func(txn *badger.Txn) error {
+		if err := setJSON(txn, watchKey(watch.ID), watch); err != nil {
+			return err
+		}
+		return txn.Set(watchAddressKey(watch.Address, watch.ID), []byte(watch.ID))
+	})
+}
+
+// RemoveWatch deletes a watch and returns it, the deposits and deliveries it
+// leaves behind are dropped when they are next processed
+func (w *WebhookProvider) RemoveWatch(id string) (*Watch, error) {
+	watch := &Watch{}
+	err := w.badgerDb.Update(func(txn *badger.Txn) error {
+		if err := getJSON(txn, watchKey(id), watch); err != nil {
+			return err
+		}
+		if err := txn.Delete(watchKey(id)); err != nil {
+			return err
+		}
+		return txn.Delete(watchAddressKey(watch.Address, id))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return watch, nil
+}
+
+// GetWatch returns the watch with the given id
+func (w *WebhookProvider) GetWatch(id string) (*Watch, error) {
+	watch := &Watch{}
+	err := w.badgerDb.View(func(txn *badger.Txn) error {
+		return getJSON(txn, watchKey(id), watch)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return watch, nil
+}
+
+// ListWatches returns every watch ordered by id
+func (w *WebhookProvider) ListWatches() ([]*Watch, error) {
+	var watches []*Watch
+	err := w.badgerDb.View(func(txn *badger.Txn) error {
+		return scanJSON(txn, []byte(watchPrefix), func() interface{} {
+			watch := &Watch{}
+			watches = append(watches, watch)
+			return watch
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return watches, nil
+}
+
+// WatchesOf returns the watches registered for an address
+func (w *WebhookProvider) WatchesOf(address string) ([]*Watch, error) {
+	var watches []*Watch
+	err := w.badgerDb.View(func(txn *badger.Txn) error {
+		prefix := []byte(watchAddressPrefix + address + "/")
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			id, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			watch := &Watch{}
+			if err := getJSON(txn, watchKey(string(id)), watch); err != nil {
+				return err
+			}
+			watches = append(watches, watch)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return watches, nil
+}
+
+// StoreDeposit starts tracking a deposit
+func (w *WebhookProvider) StoreDeposit(deposit *Deposit) error {
+	return w.badgerDb.Update(func(txn *badger.Txn) error {
+		return setDeposit(txn, deposit)
+	})
+}
+
+// Deposits returns the tracked deposits included in the block at the given height
+func (w *WebhookProvider) Deposits(index int64) ([]*Deposit, error) {
+	prefix := fmt.Sprintf("%s%020d/", depositPrefix, index)
+
+	var deposits []*Deposit
+	err := w.badgerDb.View(func(txn *badger.Txn) error {
+		return scanJSON(txn, []byte(prefix), func() interface{} {
+			deposit := &Deposit{}
+			deposits = append(deposits, deposit)
+			return deposit
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deposits, nil
+}
+
+// DueDeposits returns the deposits due at the given tip height or before,
+// ordered by due height
+func (w *WebhookProvider) DueDeposits(tip int64) ([]*Deposit, error) {
+	var deposits []*Deposit
+	err := w.badgerDb.View(func(txn *badger.Txn) error {
+		prefix := []byte(duePrefix)
+		end := []byte(fmt.Sprintf("%s%020d/", duePrefix, tip+1))
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix) && bytes.Compare(it.Item().Key(), end) < 0; it.Next() {
+			key, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			deposit := &Deposit{}
+			if err := getJSON(txn, key, deposit); err != nil {
+				return err
+			}
+			deposits = append(deposits, deposit)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deposits, nil
+}
+
+// queueDelivery assigns the next sequence to the delivery and persists it
+func queueDelivery(txn *badger.Txn, delivery *Delivery) error {
+	var next int64
+	if err := getJSON(txn, []byte(deliverySequenceKey), &next); err != nil && err != ErrNotFound {
+		return err
+	}
+
+	delivery.ID = fmt.Sprintf("%020d", next)
+	if err := setJSON(txn, deliveryKey(delivery.ID), delivery); err != nil {
+		return err
+	}
+
+	return setJSON(txn, []byte(deliverySequenceKey), next+1)
+}
+
+// ConfirmDeposit marks a deposit as confirmed, due again at the given height,
+// and queues its delivery
+func (w *WebhookProvider) ConfirmDeposit(deposit *Deposit, due int64, delivery *Delivery) error {
+	return w.badgerDb.Update(func(txn *badger.Txn) error {
+		if err := txn.Delete(dueKey(deposit)); err != nil {
+			return err
+		}
+		deposit.Confirmed = true
+		deposit.Due = due
+		if err := setDeposit(txn, deposit); err != nil {
+			return err
+		}
+		return queueDelivery(txn, delivery)
+	})
+}
+
+// RemoveDeposit stops tracking a deposit and queues the delivery, if any
+func (w *WebhookProvider) RemoveDeposit(deposit *Deposit, delivery *Delivery) error {
+	return w.badgerDb.Update(func(txn *badger.Txn) error {
+		if err := txn.Delete(depositKey(deposit)); err != nil {
+			return err
+		}
+		if err := txn.Delete(dueKey(deposit)); err != nil {
+			return err
+		}
+		if delivery == nil {
+			return nil
+		}
+		return queueDelivery(txn, delivery)
+	})
+}
+
+// DueDeliveries returns the deliveries to attempt at the given time in the
+// order they were queued
+func (w *WebhookProvider) DueDeliveries(now time.Time) ([]*Delivery, error) {
+	var deliveries []*Delivery
+	err := w.badgerDb.View(func(txn *badger.Txn) error {
+		return scanJSON(txn, []byte(deliveryPrefix), func() interface{} {
+			delivery := &Delivery{}
+			deliveries = append(deliveries, delivery)
+			return delivery
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	due := deliveries[:0]
+	for _, delivery := range deliveries {
+		if !delivery.NextAttempt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+
+	return due, nil
+}
+
+// UpdateDelivery persists the attempts of a delivery
+func (w *WebhookProvider) UpdateDelivery(delivery *Delivery) error {
+	return w.badgerDb.Update(func(txn *badger.Txn) error {
+		return setJSON(txn, deliveryKey(delivery.ID), delivery)
+	})
+}
+
+// RemoveDelivery deletes a delivery once it succeeded or has been given up on
+func (w *WebhookProvider) RemoveDelivery(id string) error {
+	return w.badgerDb.Update(func(txn *badger.Txn) error {
+		return txn.Delete(deliveryKey(id))
+	})
+}
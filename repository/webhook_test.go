@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+func TestDueDeposits(t *testing.T) {
+	db, err := zcoindtest.NewDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	webhooks := NewWebhookProvider(db.BadgerDB)
+
+	// deposits included at height 1 and 2, due at height 3 and 5
+	deposits := map[string]*Deposit{}
+	for _, deposit := range []*Deposit{
+		{WatchID: "early", BlockIdentifier: &types.BlockIdentifier{Index: 2, Hash: "b"}, Due: 3},
+		{WatchID: "late", BlockIdentifier: &types.BlockIdentifier{Index: 1, Hash: "a"}, Due: 5},
+	} {
+		deposit.TransactionIdentifier = &types.TransactionIdentifier{Hash: "tx"}
+		deposit.OperationIdentifier = &types.OperationIdentifier{Index: 1}
+		if err := webhooks.StoreDeposit(deposit); err != nil {
+			t.Fatal(err)
+		}
+		deposits[deposit.WatchID] = deposit
+	}
+
+	due := func(tip int64) []string {
+		found, err := webhooks.DueDeposits(tip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, deposit := range found {
+			ids = append(ids, deposit.WatchID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name   string
+		update func() error
+		tip    int64
+		want   []string
+	}{
+		{"none due yet", nil, 2, nil},
+		{"due at the tip", nil, 3, []string{"early"}},
+		{"due before the tip", nil, 10, []string{"early", "late"}},
+		{
+			name:   "confirmed deposit due later",
+			update: func() error { return webhooks.ConfirmDeposit(deposits["early"], 8, &Delivery{WatchID: "early"}) },
+			tip:    5,
+			want:   []string{"late"},
+		},
+		{
+			name:   "removed deposit",
+			update: func() error { return webhooks.RemoveDeposit(deposits["late"], nil) },
+			tip:    10,
+			want:   []string{"early"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.update != nil {
+				if err := test.update(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := due(test.tip); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v due at %d, want %v", got, test.tip, test.want)
+			}
+		})
+	}
+
+	if deposits, err := webhooks.Deposits(1); err != nil || len(deposits) != 0 {
+		t.Errorf("got %d deposits left at height 1 (%v), want none", len(deposits), err)
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+)
+
+type accountAPIService struct {
+	client     client.ZcoinClient
+	znodes     *mapper.ZnodeCache
+	properties *mapper.ElysiumProperties
+	blocks     *repository.BlockProvider
+	logger     *zap.Logger
+}
+
+// NewAccountAPIService creates a new service to answer balance queries
+func NewAccountAPIService(client client.ZcoinClient, znodes *mapper.ZnodeCache, blocks *repository.BlockProvider, logger *zap.Logger) server.AccountAPIServicer {
+	return &accountAPIService{
+		client:     client,
+		znodes:     znodes,
+		properties: mapper.NewElysiumProperties(),
+		blocks:     blocks,
+		logger:     logger,
+	}
+}
+
+// balanceAttempts bounds the balance reads raced by new blocks
+const balanceAttempts = 3
+
+// currentBalances returns the XZC and Elysium token balances of an address at
+// the node's tip
+func (account *accountAPIService) currentBalances(ctx context.Context, address string) ([]*types.Amount, *types.Error) {
+	balance, err := account.client.GetAddressBalance(ctx, address)
+	if err != nil {
+		return nil, logCause(ctx, account.logger, nodeError(err, ErrUnableToGetAccount), err)
+	}
+
+	balances := []*types.Amount{
+		{
+			Value:    fmt.Sprintf("%d", balance.Balance),
+			Currency: mapper.Currency,
+		},
+	}
+
+	if account.client.GetConfig().Elysium.Enabled {
+		tokens, err := account.client.GetElysiumBalances(ctx, address)
+		if err != nil {
+			return nil, logCause(ctx, account.logger, nodeError(err, ErrUnableToGetAccount), err)
+		}
+		for i := range tokens {
+			divisible, err := account.properties.Divisible(ctx, account.client, tokens[i].PropertyID)
+			if err != nil {
+				return nil, logCause(ctx, account.logger, nodeError(err, ErrUnableToGetAccount), err)
+			}
+			amount, err := mapper.ElysiumBalance(&tokens[i], divisible)
+			if err != nil {
+				return nil, logCause(ctx, account.logger, ErrMalformedValue, err)
+			}
+			balances = append(balances, amount)
+		}
+	}
+
+	return balances, nil
+}
+
+// AccountBalance returns the balance of an address at the current tip, the
+// node has to run with -addressindex. Elysium token balances follow the XZC one
+// and the Znodes the address is collateral or payee of are in the metadata.
+// Balances at a given block are computed from the indexed operations instead.
+func (account *accountAPIService) AccountBalance(
+	ctx context.Context,
+	request *types.AccountBalanceRequest,
+) (*types.AccountBalanceResponse, *types.Error) {
+	terr := ValidateNetworkIdentifier(ctx, account.client, request.NetworkIdentifier)
+	if terr != nil {
+		return nil, terr
+	}
+
+	if request.AccountIdentifier.SubAccount != nil {
+		return nil, ErrInvalidAccountAddress
+	}
+	if request.BlockIdentifier != nil {
+		return account.historicalBalance(ctx, request.AccountIdentifier.Address, request.BlockIdentifier)
+	}
+	if client.IsPseudoAddress(request.AccountIdentifier.Address) {
+		return nil, ErrPseudoAccountBalance
+	}
+
+	// the node reports balances at its tip only, the tip is read on both sides
+	// of the balance so the block returned is the one the balance is at
+	status, err := account.client.GetStatus(ctx)
+	if err != nil {
+		return nil, logCause(ctx, account.logger, nodeError(err, ErrUnableToGetNodeStatus), err)
+	}
+	var balances []*types.Amount
+	for attempt := 1; ; attempt++ {
+		balances, terr = account.currentBalances(ctx, request.AccountIdentifier.Address)
+		if terr != nil {
+			return nil, terr
+		}
+
+		after, err := account.client.GetStatus(ctx)
+		if err != nil {
+			return nil, logCause(ctx, account.logger, nodeError(err, ErrUnableToGetNodeStatus), err)
+		}
+		if after.BestBlockHash == status.BestBlockHash {
+			break
+		}
+		if attempt == balanceAttempts {
+			return nil, withDetails(ErrTipChanged, map[string]interface{}{
+				"attempts": attempt,
+			})
+		}
+		status = after
+	}
+
+	response := &types.AccountBalanceResponse{
+		BlockIdentifier: &types.BlockIdentifier{
+			Index: int64(status.Blocks),
+			Hash:  status.BestBlockHash,
+		},
+		Balances: balances,
+	}
+	registered, err := account.znodes.Get(ctx, account.client, response.BlockIdentifier)
+	if err != nil {
+		return nil, logCause(ctx, account.logger, znodeError(err), err)
+	}
+	if znodes := registered.Account(request.AccountIdentifier.Address); len(znodes) > 0 {
+		response.Metadata = map[string]interface{}{
+			"znodes": znodes,
+		}
+	}
+
+	return response, nil
+}
+
+// historicalBalance replays the balance deltas the indexer stored for an
+// address up to the requested block. No node call is involved so
+// pseudo-accounts have a balance too.
+func (account *accountAPIService) historicalBalance(
+	ctx context.Context,
+	address string,
+	partial *types.PartialBlockIdentifier,
+) (*types.AccountBalanceResponse, *types.Error) {
+	block, terr := account.indexedBlock(ctx, partial)
+	if terr != nil {
+		return nil, terr
+	}
+
+	amounts, err := account.blocks.GetBalances(address, block.Index)
+	if err != nil {
+		return nil, logCause(ctx, account.logger, ErrUnableToGetAccount, err)
+	}
+
+	balances := []*types.Amount{
+		{
+			Value:    "0",
+			Currency: mapper.Currency,
+		},
+	}
+	for _, amount := range amounts {
+		if amount.Currency.Symbol == mapper.Currency.Symbol {
+			balances[0] = amount
+			continue
+		}
+		balances = append(balances, amount)
+	}
+
+	return &types.AccountBalanceResponse{
+		BlockIdentifier: block,
+		Balances:        balances,
+	}, nil
+}
+
+// indexedBlock resolves a partial block identifier against the indexed blocks,
+// an empty one refers to the indexed tip
+func (account *accountAPIService) indexedBlock(ctx context.Context, partial *types.PartialBlockIdentifier) (*types.BlockIdentifier, *types.Error) {
+	tip, err := account.blocks.GetTip()
+	if err == repository.ErrNotFound {
+		return nil, blockNotIndexed(nil)
+	}
+	if err != nil {
+		return nil, logCause(ctx, account.logger, ErrUnableToGetBlk, err)
+	}
+
+	var block *types.BlockResponse
+	switch {
+	case partial.Index != nil:
+		if *partial.Index > tip.Index {
+			return nil, blockNotIndexed(tip)
+		}
+		block, err = account.blocks.GetBlockByIndex(*partial.Index)
+	case partial.Hash != nil:
+		block, err = account.blocks.GetBlockByHash(*partial.Hash)
+	default:
+		return tip, nil
+	}
+	if err == repository.ErrNotFound {
+		return nil, blockNotIndexed(tip)
+	}
+	if err != nil {
+		return nil, logCause(ctx, account.logger, ErrUnableToGetBlk, err)
+	}
+
+	identifier := block.Block.BlockIdentifier
+	if partial.Hash != nil && identifier.Hash != *partial.Hash {
+		return nil, blockNotIndexed(tip)
+	}
+
+	return identifier, nil
+}
+
+// blockNotIndexed reports the indexed tip along with ErrBlockNotIndexed
+func blockNotIndexed(tip *types.BlockIdentifier) *types.Error {
+	if tip == nil {
+		return ErrBlockNotIndexed
+	}
+
+	return withDetails(ErrBlockNotIndexed, map[string]interface{}{
+		"indexed_tip": tip,
+	})
+}
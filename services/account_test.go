@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+// racingClient mines a block after each of the first races balance reads
+type racingClient struct {
+	client.ZcoinClient
+	node  *zcoindtest.Node
+	races int
+}
+
+func (racing *racingClient) GetAddressBalance(ctx context.Context, address string) (*client.AddressBalanceResult, error) {
+	balance, err := racing.ZcoinClient.GetAddressBalance(ctx, address)
+	if racing.races > 0 {
+		racing.races--
+		racing.node.Mine()
+	}
+	return balance, err
+}
+
+func TestAccountBalanceTip(t *testing.T) {
+	tests := []struct {
+		name  string
+		races int
+		want  *types.Error
+	}{
+		{"steady tip", 0, nil},
+		{"block mined once", 1, nil},
+		{"blocks mined on every attempt", balanceAttempts, ErrTipChanged},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node, stub := newChain(2)
+			defer stub.Close()
+			racing := &racingClient{
+				ZcoinClient: client.NewZcoinClient(stub.Config(), zap.NewNop()),
+				node:        node,
+				races:       test.races,
+			}
+			service := NewAccountAPIService(racing, mapper.NewZnodeCache(), nil, zap.NewNop())
+
+			response, terr := service.AccountBalance(context.Background(), &types.AccountBalanceRequest{
+				NetworkIdentifier: network,
+				AccountIdentifier: &types.AccountIdentifier{Address: alice},
+			})
+			if test.want != nil {
+				if terr == nil || terr.Code != test.want.Code {
+					t.Errorf("got %+v, want %s", terr, test.want.Message)
+				}
+				return
+			}
+			if terr != nil {
+				t.Fatal(terr)
+			}
+
+			// the tip no longer moves, the balance has to be the one at the tip returned
+			balance, err := racing.ZcoinClient.GetAddressBalance(context.Background(), alice)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tip := node.BlockAt(node.Height())
+			if response.BlockIdentifier.Hash != tip.Hash || response.Balances[0].Value != fmt.Sprintf("%d", balance.Balance) {
+				t.Errorf("got %s at %d, want %d at %d", response.Balances[0].Value, response.BlockIdentifier.Index, balance.Balance, tip.Height)
+			}
+		})
+	}
+}
+
+func TestAccountBalanceErrors(t *testing.T) {
+	_, stub := newChain(2)
+	defer stub.Close()
+	db, err := zcoindtest.NewDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	blocks := repository.NewBlockProvider(db.BadgerDB)
+	service := NewAccountAPIService(client.NewZcoinClient(stub.Config(), zap.NewNop()), mapper.NewZnodeCache(), blocks, zap.NewNop())
+
+	zero := int64(0)
+	tests := []struct {
+		name    string
+		address string
+		block   *types.PartialBlockIdentifier
+		want    *types.Error
+	}{
+		{"pseudo-account at the tip", client.SIGMA_POOL_ADDRESS, nil, ErrPseudoAccountBalance},
+		{"nothing indexed", alice, &types.PartialBlockIdentifier{Index: &zero}, ErrBlockNotIndexed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, terr := service.AccountBalance(context.Background(), &types.AccountBalanceRequest{
+				NetworkIdentifier: network,
+				AccountIdentifier: &types.AccountIdentifier{Address: test.address},
+				BlockIdentifier:   test.block,
+			})
+			if terr == nil || terr.Code != test.want.Code {
+				t.Errorf("got %+v, want %s", terr, test.want.Message)
+			}
+		})
+	}
+}
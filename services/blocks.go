@@ -2,157 +2,159 @@ package services
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/btcsuite/btcd/btcjson"
 	"github.com/coinbase/rosetta-sdk-go/server"
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
 	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
-	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
 )
 
 // BlockAPIService client based implementation of the block servicer
 type blockAPIService struct {
 	server.BlockAPIServicer
-	client          client.ZcoinClient
-	blockRepository repository.BlockProvider
+	client client.ZcoinClient
+	znodes *mapper.ZnodeCache
+	blocks *mapper.BlockResponseCache
+	logger *zap.Logger
 }
 
 // NewBlockAPIService creates a new block API service
-func NewBlockAPIService(client client.ZcoinClient) server.BlockAPIServicer {
+func NewBlockAPIService(client client.ZcoinClient, znodes *mapper.ZnodeCache, blocks *mapper.BlockResponseCache, logger *zap.Logger) server.BlockAPIServicer {
 	return &blockAPIService{
 		client: client,
+		znodes: znodes,
+		blocks: blocks,
+		logger: logger,
 	}
 }
 
-func mapTransactions(txs []string) []*types.TransactionIdentifier {
-	var transactionsIdentifiers []*types.TransactionIdentifier
-	for i := 0; i < len(txs); i++ {
-		transactionsIdentifiers = append(transactionsIdentifiers, &types.TransactionIdentifier{
-			Hash: txs[i],
-		})
+func (blockService *blockAPIService) retriveHash(ctx context.Context, blockIdentifier *types.PartialBlockIdentifier) (string, *types.Error) {
+	if blockIdentifier.Hash != nil {
+		return *blockIdentifier.Hash, nil
+	}
+
+	if blockIdentifier.Index != nil {
+		block, err := blockService.client.GetBlock(ctx, *blockIdentifier.Index)
+		if err != nil {
+			terr := withDetails(blockError(err), map[string]interface{}{
+				"index": *blockIdentifier.Index,
+			})
+			return "", logCause(ctx, blockService.logger, terr, err)
+		}
+		return block.Hash, nil
 	}
 
-	return transactionsIdentifiers
+	status, err := blockService.client.GetStatus(ctx)
+	if err != nil {
+		return "", logCause(ctx, blockService.logger, nodeError(err, ErrUnableToGetNodeStatus), err)
+	}
+	return status.BestBlockHash, nil
 }
 
-func (blockService *blockAPIService) retriveBlock(ctx context.Context, blockRequest *types.BlockRequest) (*btcjson.GetBlockVerboseResult, *btcjson.GetBlockVerboseResult, *types.Error) {
+// checkIndex reports a block requested by hash and index whose index is not
+// the requested one
+func checkIndex(blockIdentifier *types.BlockIdentifier, index *int64) *types.Error {
+	if index == nil || *index == blockIdentifier.Index {
+		return nil
+	}
 
-	var block, prevBlock *btcjson.GetBlockVerboseResult
-	var err error
+	return withDetails(ErrBlockMismatch, map[string]interface{}{
+		"hash":            blockIdentifier.Hash,
+		"requested_index": *index,
+		"index":           blockIdentifier.Index,
+	})
+}
 
-	if blockRequest.BlockIdentifier.Index != nil {
-		block, err = blockService.client.GetBlock(ctx, *blockRequest.BlockIdentifier.Index)
+// retriveBlock returns the mapped block, blocks requested by hash and final
+// blocks requested by height are served from the cache when possible
+func (blockService *blockAPIService) retriveBlock(ctx context.Context, blockIdentifier *types.PartialBlockIdentifier) (*types.Block, *types.Error) {
+	if blockIdentifier.Hash == nil && blockIdentifier.Index != nil {
+		if rosettaBlock := blockService.blocks.GetByIndex(*blockIdentifier.Index); rosettaBlock != nil {
+			return rosettaBlock, nil
+		}
+	}
 
-		if *blockRequest.BlockIdentifier.Index == 0 {
-			prevBlock = &btcjson.GetBlockVerboseResult{
-				Hash: "0x0",
-			}
-		} else {
-			prevBlock, err = blockService.client.GetBlock(ctx, *blockRequest.BlockIdentifier.Index-1)
+	hash, terr := blockService.retriveHash(ctx, blockIdentifier)
+	if terr != nil {
+		return nil, terr
+	}
+
+	rosettaBlock := blockService.blocks.Get(hash)
+	if rosettaBlock == nil {
+		block, err := blockService.client.GetBlockByHashWithTransaction(ctx, hash)
+		if err != nil {
+			terr := withDetails(blockError(err), map[string]interface{}{
+				"hash": hash,
+			})
+			return nil, logCause(ctx, blockService.logger, terr, err)
 		}
-	} else if blockRequest.BlockIdentifier.Hash != nil {
-		block, err = blockService.client.GetBlockByHash(ctx, *blockRequest.BlockIdentifier.Hash)
 
+		znodes, err := blockService.znodes.Get(ctx, blockService.client, &types.BlockIdentifier{
+			Index: block.Height,
+			Hash:  block.Hash,
+		})
 		if err != nil {
-			return nil, nil, ErrUnableToGetBlk
+			terr := withDetails(znodeError(err), map[string]interface{}{
+				"hash": hash,
+			})
+			return nil, logCause(ctx, blockService.logger, terr, err)
 		}
-		prevBlock, err = blockService.client.GetBlockByHash(ctx, block.PreviousHash)
-	} else {
-		block, err := blockService.client.GetLatestBlock(ctx)
+		rosettaBlock, err = mapper.Block(ctx, blockService.client, block, znodes, blockService.logger)
 		if err != nil {
-			return nil, nil, ErrUnableToGetBlk
+			terr := withDetails(ErrUnableToGetTxns, map[string]interface{}{
+				"hash": hash,
+			})
+			return nil, logCause(ctx, blockService.logger, terr, err)
 		}
-		prevBlock, err = blockService.client.GetBlockByHash(ctx, block.Header.PrevBlock.String())
+		blockService.blocks.Add(rosettaBlock)
 	}
 
-	if err != nil {
-		return nil, nil, ErrUnableToGetBlk
+	if terr := checkIndex(rosettaBlock.BlockIdentifier, blockIdentifier.Index); terr != nil {
+		return nil, terr
 	}
 
-	return block, prevBlock, nil
+	return rosettaBlock, nil
 }
 
 // Block retrieves the block for a given candidate
 func (blockService *blockAPIService) Block(ctx context.Context, blockRequest *types.BlockRequest) (*types.BlockResponse, *types.Error) {
-	block, prevBlock, err := blockService.retriveBlock(ctx, blockRequest)
-
-	if err != nil {
-		return nil, err
+	rosettaBlock, terr := blockService.retriveBlock(ctx, blockRequest.BlockIdentifier)
+	if terr != nil {
+		return nil, terr
 	}
 
 	return &types.BlockResponse{
-		Block: &types.Block{
-			BlockIdentifier: &types.BlockIdentifier{
-				Hash: block.Hash,
-			},
-			ParentBlockIdentifier: &types.BlockIdentifier{
-				Hash: prevBlock.Hash,
-			},
-			Timestamp: block.Time,
-		},
-		OtherTransactions: mapTransactions(block.Tx),
+		Block: rosettaBlock,
 	}, nil
 }
 
+// txNotInBlock reports a transaction the requested block does not contain
+func txNotInBlock(blockTransaction *types.BlockTransactionRequest) *types.Error {
+	return withDetails(ErrTxNotInBlock, map[string]interface{}{
+		"block_hash":       blockTransaction.BlockIdentifier.Hash,
+		"transaction_hash": blockTransaction.TransactionIdentifier.Hash,
+	})
+}
+
 // BlockTransaction retrieves the block with the given transactions included
 func (blockService *blockAPIService) BlockTransaction(ctx context.Context, blockTransaction *types.BlockTransactionRequest) (*types.BlockTransactionResponse, *types.Error) {
-	block, err := blockService.client.GetBlockByHashWithTransaction(ctx, blockTransaction.BlockIdentifier.Hash)
-
-	if err != nil {
-		return nil, ErrUnableToGetBlk
+	// the whole block is mapped since its coinbase claims the fees of the others
+	rosettaBlock, terr := blockService.retriveBlock(ctx, &types.PartialBlockIdentifier{
+		Hash:  &blockTransaction.BlockIdentifier.Hash,
+		Index: &blockTransaction.BlockIdentifier.Index,
+	})
+	if terr != nil {
+		return nil, terr
 	}
 
-	var networkIndex *int64 = new(int64)
-	*networkIndex = 0
-
-	for index, tx := range block.Tx {
-		if tx.Hash == blockTransaction.TransactionIdentifier.Hash {
-			txOperations := make([]*types.Operation, 0)
-
-			for _, vOut := range block.Tx[index].Vout {
-
-				if !client.IsValidPaymentType(vOut.ScriptPubKey.Type) {
-					continue
-				}
-
-				for _, address := range vOut.ScriptPubKey.Addresses {
-					txOperations = append(txOperations, &types.Operation{
-						OperationIdentifier: &types.OperationIdentifier{
-							Index:        int64(vOut.N),
-							NetworkIndex: networkIndex,
-						},
-						Type:   client.Transfer,
-						Status: client.StatusSuccess,
-						Account: &types.AccountIdentifier{
-							Address: address,
-						},
-						Amount: &types.Amount{
-							Value: fmt.Sprintf("%d", int64(vOut.Value*client.BASE_CURRENCY_DECIMAL_DIVIDER)),
-							Currency: &types.Currency{
-								Decimals: client.BASE_CURRENCY_DECIMAL_COUNT,
-								Symbol:   client.CURRENCY_SYMBOL,
-							},
-						},
-					})
-				}
-
-			}
-
+	for _, transaction := range rosettaBlock.Transactions {
+		if transaction.TransactionIdentifier.Hash == blockTransaction.TransactionIdentifier.Hash {
 			return &types.BlockTransactionResponse{
-				Transaction: &types.Transaction{
-					TransactionIdentifier: &types.TransactionIdentifier{
-						Hash: tx.Hash,
-					},
-					Metadata: map[string]interface{}{
-						"size":     tx.Size,
-						"lockTime": tx.LockTime,
-					},
-					Operations: txOperations,
-				},
+				Transaction: transaction,
 			}, nil
 		}
 	}
 
-	return nil, ErrUnableToGetTxns
+	return nil, txNotInBlock(blockTransaction)
 }
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+)
+
+// countingClient counts the blocks fetched from the node
+type countingClient struct {
+	client.ZcoinClient
+	mu      sync.Mutex
+	fetches int
+}
+
+func (counting *countingClient) fetched() {
+	counting.mu.Lock()
+	defer counting.mu.Unlock()
+
+	counting.fetches++
+}
+
+func (counting *countingClient) GetBlock(ctx context.Context, height int64) (*btcjson.GetBlockVerboseResult, error) {
+	counting.fetched()
+	return counting.ZcoinClient.GetBlock(ctx, height)
+}
+
+func (counting *countingClient) GetBlockByHashWithTransaction(ctx context.Context, hash string) (*client.GetBlockVerboseTxResult, error) {
+	counting.fetched()
+	return counting.ZcoinClient.GetBlockByHashWithTransaction(ctx, hash)
+}
+
+func (counting *countingClient) count() int {
+	counting.mu.Lock()
+	defer counting.mu.Unlock()
+
+	return counting.fetches
+}
+
+func TestBlockErrors(t *testing.T) {
+	node, stub := newChain(3)
+	defer stub.Close()
+	service := NewBlockAPIService(client.NewZcoinClient(stub.Config(), zap.NewNop()), mapper.NewZnodeCache(), mapper.NewBlockResponseCache(0, 0), zap.NewNop())
+	ctx := context.Background()
+
+	tip := &types.BlockIdentifier{Index: node.Height(), Hash: node.BlockAt(node.Height()).Hash}
+	unknown := node.Height() + 10
+	one := int64(1)
+	unknownHash := "00000000000000000000000000000000000000000000000000000000deadbeef"
+	tests := []struct {
+		name      string
+		call      func() *types.Error
+		code      int32
+		detail    string
+		retriable bool
+	}{
+		{
+			name: "height above the tip",
+			call: func() *types.Error {
+				_, terr := service.Block(ctx, &types.BlockRequest{
+					NetworkIdentifier: network,
+					BlockIdentifier:   &types.PartialBlockIdentifier{Index: &unknown},
+				})
+				return terr
+			},
+			code:      ErrBlockNotYetAvailable.Code,
+			detail:    "index",
+			retriable: true,
+		},
+		{
+			name: "unknown hash",
+			call: func() *types.Error {
+				_, terr := service.Block(ctx, &types.BlockRequest{
+					NetworkIdentifier: network,
+					BlockIdentifier:   &types.PartialBlockIdentifier{Hash: &unknownHash},
+				})
+				return terr
+			},
+			code:   ErrBlockNotFound.Code,
+			detail: "hash",
+		},
+		{
+			name: "hash of another height",
+			call: func() *types.Error {
+				_, terr := service.Block(ctx, &types.BlockRequest{
+					NetworkIdentifier: network,
+					BlockIdentifier:   &types.PartialBlockIdentifier{Index: &one, Hash: &tip.Hash},
+				})
+				return terr
+			},
+			code:   ErrBlockMismatch.Code,
+			detail: "requested_index",
+		},
+		{
+			name: "transaction of another block",
+			call: func() *types.Error {
+				_, terr := service.BlockTransaction(ctx, &types.BlockTransactionRequest{
+					NetworkIdentifier:     network,
+					BlockIdentifier:       tip,
+					TransactionIdentifier: &types.TransactionIdentifier{Hash: node.BlockAt(1).Tx[0].Txid},
+				})
+				return terr
+			},
+			code:   ErrTxNotInBlock.Code,
+			detail: "transaction_hash",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			terr := test.call()
+			if terr == nil || terr.Code != test.code || terr.Details[test.detail] == nil {
+				t.Fatalf("got %+v, want code %d with %s", terr, test.code, test.detail)
+			}
+			if terr.Retriable != test.retriable || terr.Details["error"] != nil {
+				t.Errorf("got %+v, want retriable %t without the cause", terr, test.retriable)
+			}
+		})
+	}
+}
+
+func TestBlockCache(t *testing.T) {
+	node, stub := newChain(5)
+	defer stub.Close()
+	counting := &countingClient{ZcoinClient: client.NewZcoinClient(stub.Config(), zap.NewNop())}
+	blocks := mapper.NewBlockResponseCache(0, 2)
+	service := NewBlockAPIService(counting, mapper.NewZnodeCache(), blocks, zap.NewNop())
+	ctx := context.Background()
+
+	// the indexer has reached the tip
+	tip, terr := service.Block(ctx, &types.BlockRequest{NetworkIdentifier: network, BlockIdentifier: &types.PartialBlockIdentifier{}})
+	if terr != nil {
+		t.Fatal(terr)
+	}
+	blocks.BlockAdded(tip.Block)
+
+	final := int64(1)
+	nearTip := node.Height()
+	nearTipHash := node.BlockAt(nearTip).Hash
+	tests := []struct {
+		name    string
+		partial *types.PartialBlockIdentifier
+		cached  bool
+	}{
+		{"final by height", &types.PartialBlockIdentifier{Index: &final}, true},
+		{"near-tip by hash", &types.PartialBlockIdentifier{Hash: &nearTipHash}, true},
+		{"near-tip by height", &types.PartialBlockIdentifier{Index: &nearTip}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := &types.BlockRequest{NetworkIdentifier: network, BlockIdentifier: test.partial}
+			first, terr := service.Block(ctx, request)
+			if terr != nil {
+				t.Fatal(terr)
+			}
+			fetches := counting.count()
+			second, terr := service.Block(ctx, request)
+			if terr != nil {
+				t.Fatal(terr)
+			}
+			if second.Block.BlockIdentifier.Hash != first.Block.BlockIdentifier.Hash {
+				t.Errorf("got %s, then %s", first.Block.BlockIdentifier.Hash, second.Block.BlockIdentifier.Hash)
+			}
+			if cached := counting.count() == fetches; cached != test.cached {
+				t.Errorf("got cached %t, want %t", cached, test.cached)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+)
+
+// EventsBlocksRequest reads the block event log starting at the Offset
+// sequence, consumers resume from the sequence after the last one they saw
+type EventsBlocksRequest struct {
+	NetworkIdentifier *types.NetworkIdentifier `json:"network_identifier"`
+	Offset            int64                    `json:"offset,omitempty"`
+	Limit             int                      `json:"limit,omitempty"`
+}
+
+// EventsBlocksResponse contains the events read in sequence order and the
+// sequence of the latest event, -1 when nothing has been indexed yet
+type EventsBlocksResponse struct {
+	MaxSequence int64                    `json:"max_sequence"`
+	Events      []*repository.BlockEvent `json:"events"`
+}
+
+// EventsAPIServicer defines the api actions for the events endpoints
+type EventsAPIServicer interface {
+	EventsBlocks(context.Context, *EventsBlocksRequest) (*EventsBlocksResponse, *types.Error)
+}
+
+type eventsAPIService struct {
+	client client.ZcoinClient
+	blocks *repository.BlockProvider
+	logger *zap.Logger
+}
+
+// NewEventsAPIService creates a new service serving the block event log
+func NewEventsAPIService(client client.ZcoinClient, blocks *repository.BlockProvider, logger *zap.Logger) EventsAPIServicer {
+	return &eventsAPIService{
+		client: client,
+		blocks: blocks,
+		logger: logger,
+	}
+}
+
+// EventsBlocks returns the blocks added and removed by the indexer in the
+// order it processed them
+func (events *eventsAPIService) EventsBlocks(
+	ctx context.Context,
+	request *EventsBlocksRequest,
+) (*EventsBlocksResponse, *types.Error) {
+	terr := ValidateNetworkIdentifier(ctx, events.client, request.NetworkIdentifier)
+	if terr != nil {
+		return nil, terr
+	}
+
+	blockEvents, maxSequence, err := events.blocks.GetBlockEvents(request.Offset, pageLimit(request.Limit))
+	if err != nil {
+		return nil, logCause(ctx, events.logger, ErrUnableToGetEvents, err)
+	}
+	if blockEvents == nil {
+		blockEvents = []*repository.BlockEvent{}
+	}
+
+	return &EventsBlocksResponse{
+		MaxSequence: maxSequence,
+		Events:      blockEvents,
+	}, nil
+}
+
+// EventsAPIController binds the events endpoints to an EventsAPIServicer
+type EventsAPIController struct {
+	service  EventsAPIServicer
+	asserter *asserter.Asserter
+}
+
+// NewEventsAPIController creates a controller for the events endpoints
+func NewEventsAPIController(s EventsAPIServicer, asserter *asserter.Asserter) server.Router {
+	return &EventsAPIController{
+		service:  s,
+		asserter: asserter,
+	}
+}
+
+// Routes returns all of the api routes for the EventsAPIController
+func (c *EventsAPIController) Routes() server.Routes {
+	return server.Routes{
+		{
+			Name:        "EventsBlocks",
+			Method:      http.MethodPost,
+			Pattern:     "/events/blocks",
+			HandlerFunc: c.EventsBlocks,
+		},
+	}
+}
+
+// EventsBlocks - Get a range of BlockEvents
+func (c *EventsAPIController) EventsBlocks(w http.ResponseWriter, r *http.Request) {
+	request := &EventsBlocksRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	if err := c.assertEventsBlocksRequest(request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	result, serviceErr := c.service.EventsBlocks(r.Context(), request)
+	if serviceErr != nil {
+		server.EncodeJSONResponse(serviceErr, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	server.EncodeJSONResponse(result, http.StatusOK, w)
+}
+
+func (c *EventsAPIController) assertEventsBlocksRequest(request *EventsBlocksRequest) error {
+	if err := c.asserter.ValidSupportedNetwork(request.NetworkIdentifier); err != nil {
+		return err
+	}
+	if request.Offset < 0 {
+		return errors.Errorf("offset %d is negative", request.Offset)
+	}
+
+	return nil
+}
@@ -6,135 +6,143 @@
 
 package services
 
-import "github.com/coinbase/rosetta-sdk-go/types"
-
-var (
-	ErrUnableToGetChainID = &types.Error{
-		Code:      1,
-		Message:   "unable to get chain ID",
-		Retriable: true,
-	}
-
-	ErrInvalidBlockchain = &types.Error{
-		Code:      2,
-		Message:   "invalid blockchain specified in network identifier",
-		Retriable: false,
-	}
-
-	ErrInvalidSubnetwork = &types.Error{
-		Code:      3,
-		Message:   "invalid sub-network identifier",
-		Retriable: false,
-	}
-
-	ErrInvalidNetwork = &types.Error{
-		Code:      4,
-		Message:   "invalid network specified in network identifier",
-		Retriable: false,
-	}
-
-	ErrMissingNID = &types.Error{
-		Code:      5,
-		Message:   "network identifier is missing",
-		Retriable: false,
-	}
-
-	ErrUnableToGetLatestBlk = &types.Error{
-		Code:      6,
-		Message:   "unable to get latest block",
-		Retriable: true,
-	}
-
-	ErrUnableToGetGenesisBlk = &types.Error{
-		Code:      7,
-		Message:   "unable to get genesis block",
-		Retriable: true,
-	}
-
-	ErrUnableToGetAccount = &types.Error{
-		Code:      8,
-		Message:   "unable to get account",
-		Retriable: true,
-	}
-
-	ErrMustQueryByIndex = &types.Error{
-		Code:      9,
-		Message:   "blocks must be queried by index and not hash",
-		Retriable: false,
-	}
-
-	ErrInvalidAccountAddress = &types.Error{
-		Code:      10,
-		Message:   "invalid account address",
-		Retriable: false,
-	}
-
-	ErrMustSpecifySubAccount = &types.Error{
-		Code:      11,
-		Message:   "a valid subaccount must be specified ('general' or 'escrow')",
-		Retriable: false,
-	}
-
-	ErrUnableToGetBlk = &types.Error{
-		Code:      12,
-		Message:   "unable to get block",
-		Retriable: true,
-	}
-
-	ErrNotImplemented = &types.Error{
-		Code:      13,
-		Message:   "operation not implemented",
-		Retriable: false,
-	}
-
-	ErrUnableToGetTxns = &types.Error{
-		Code:      14,
-		Message:   "unable to get transactions",
-		Retriable: true,
-	}
-
-	ErrUnableToSubmitTx = &types.Error{
-		Code:      15,
-		Message:   "unable to submit transaction",
-		Retriable: false,
-	}
-
-	ErrUnableToGetNextNonce = &types.Error{
-		Code:      16,
-		Message:   "unable to get next nonce",
-		Retriable: true,
-	}
-
-	ErrMalformedValue = &types.Error{
-		Code:      17,
-		Message:   "malformed value",
-		Retriable: false,
-	}
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/logging"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+)
 
-	ErrUnableToGetNodeStatus = &types.Error{
-		Code:      18,
-		Message:   "unable to get node status",
-		Retriable: true,
-	}
+// ErrorList holds every error the gateway can return, errors are added by
+// newError so /network/options never misses one
+var ErrorList []*types.Error
 
-	ErrorList = []*types.Error{
-		ErrUnableToGetChainID,
-		ErrInvalidBlockchain,
-		ErrInvalidSubnetwork,
-		ErrInvalidNetwork,
-		ErrMissingNID,
-		ErrUnableToGetLatestBlk,
-		ErrUnableToGetGenesisBlk,
-		ErrUnableToGetAccount,
-		ErrMustQueryByIndex,
-		ErrInvalidAccountAddress,
-		ErrMustSpecifySubAccount,
-		ErrUnableToGetBlk,
-		ErrNotImplemented,
-		ErrUnableToGetTxns,
-		ErrUnableToSubmitTx,
-		ErrUnableToGetNextNonce,
-		ErrMalformedValue,
-		ErrUnableToGetNodeStatus,
-	}
+var (
+	ErrUnableToGetChainID     = newError(1, "unable to get chain ID", true)
+	ErrInvalidBlockchain      = newError(2, "invalid blockchain specified in network identifier", false)
+	ErrInvalidSubnetwork      = newError(3, "invalid sub-network identifier", false)
+	ErrInvalidNetwork         = newError(4, "invalid network specified in network identifier", false)
+	ErrMissingNID             = newError(5, "network identifier is missing", false)
+	ErrUnableToGetLatestBlk   = newError(6, "unable to get latest block", true)
+	ErrUnableToGetGenesisBlk  = newError(7, "unable to get genesis block", true)
+	ErrUnableToGetAccount     = newError(8, "unable to get account", true)
+	ErrMustQueryByIndex       = newError(9, "blocks must be queried by index and not hash", false)
+	ErrInvalidAccountAddress  = newError(10, "invalid account address", false)
+	ErrMustSpecifySubAccount  = newError(11, "a valid subaccount must be specified ('general' or 'escrow')", false)
+	ErrUnableToGetBlk         = newError(12, "unable to get block", true)
+	ErrNotImplemented         = newError(13, "operation not implemented", false)
+	ErrUnableToGetTxns        = newError(14, "unable to get transactions", true)
+	ErrUnableToSubmitTx       = newError(15, "unable to submit transaction", false)
+	ErrUnableToGetNextNonce   = newError(16, "unable to get next nonce", true)
+	ErrMalformedValue         = newError(17, "malformed value", false)
+	ErrUnableToGetNodeStatus  = newError(18, "unable to get node status", true)
+	ErrPseudoAccountBalance   = newError(19, "pseudo-account balances are not reported by the node", false)
+	ErrInvalidSearchCursor    = newError(20, "invalid search cursor", false)
+	ErrUnableToSearchTxns     = newError(21, "unable to search transactions", true)
+	ErrBlockNotIndexed        = newError(22, "block has not been indexed", true)
+	ErrUnableToGetEvents      = newError(23, "unable to get block events", true)
+	ErrInvalidSubscription    = newError(24, "invalid subscription request", false)
+	ErrInvalidWebhook         = newError(25, "invalid webhook", false)
+	ErrWebhookNotFound        = newError(26, "webhook not found", false)
+	ErrUnableToUpdateWebhooks = newError(27, "unable to update webhooks", true)
+	ErrBlockNotFound          = newError(28, "block not found", false)
+	ErrTxNotInBlock           = newError(29, "transaction not found in block", false)
+	ErrBlockMismatch          = newError(30, "block hash and index do not match", false)
+	ErrNodeUnavailable        = newError(31, "node unavailable", true)
+	ErrNodeSyncing            = newError(32, "node is still syncing", true)
+	ErrBlockOrphaned          = newError(33, "block is no longer on the main chain", false)
+	ErrTipChanged             = newError(34, "tip changed while reading the balance", true)
+	ErrBlockNotYetAvailable   = newError(35, "block not yet available", true)
 )
+
+// newError creates an error and adds it to ErrorList, codes must be unique
+func newError(code int32, message string, retriable bool) *types.Error {
+	for _, terr := range ErrorList {
+		if terr.Code == code {
+			panic(fmt.Sprintf("error code %d is used by %q and %q", code, terr.Message, message))
+		}
+	}
+
+	terr := &types.Error{
+		Code:      code,
+		Message:   message,
+		Retriable: retriable,
+	}
+	ErrorList = append(ErrorList, terr)
+	return terr
+}
+
+// withDetails returns a copy of terr carrying details on top of its own
+func withDetails(terr *types.Error, details map[string]interface{}) *types.Error {
+	detailed := *terr
+	detailed.Details = make(map[string]interface{}, len(terr.Details)+len(details))
+	for key, value := range terr.Details {
+		detailed.Details[key] = value
+	}
+	for key, value := range details {
+		detailed.Details[key] = value
+	}
+
+	return &detailed
+}
+
+// nodeError describes a failed node call, fallback is used when the node
+// answered with an error of its own
+func nodeError(err error, fallback *types.Error) *types.Error {
+	rpcErr, ok := errors.Cause(err).(*btcjson.RPCError)
+	if !ok {
+		return ErrNodeUnavailable
+	}
+
+	switch rpcErr.Code {
+	case btcjson.ErrRPCInWarmup, btcjson.ErrRPCClientInInitialDownload:
+		return ErrNodeSyncing
+	default:
+		return withDetails(fallback, map[string]interface{}{
+			"rpc_code": rpcErr.Code,
+		})
+	}
+}
+
+// blockError describes a failed block lookup, the node reports unknown hashes
+// as invalid keys and heights above its tip as invalid parameters. A height
+// above the tip is retriable, the block is likely to be mined shortly.
+func blockError(err error) *types.Error {
+	if rpcErr, ok := errors.Cause(err).(*btcjson.RPCError); ok {
+		switch rpcErr.Code {
+		case btcjson.ErrRPCInvalidAddressOrKey:
+			return ErrBlockNotFound
+		case btcjson.ErrRPCInvalidParameter:
+			return ErrBlockNotYetAvailable
+		}
+	}
+
+	return nodeError(err, ErrUnableToGetBlk)
+}
+
+// znodeError describes a failed Znode list lookup
+func znodeError(err error) *types.Error {
+	if errors.Cause(err) == mapper.ErrBlockOrphaned {
+		return ErrBlockOrphaned
+	}
+
+	return nodeError(err, ErrUnableToGetTxns)
+}
+
+// logCause logs the error behind a Rosetta error with the request logger and
+// returns the Rosetta error, the cause stays in the log as it may reveal the
+// node endpoint or the database paths
+func logCause(ctx context.Context, fallback *zap.Logger, terr *types.Error, err error) *types.Error {
+	logging.FromContext(ctx, fallback).Error(terr.Message,
+		zap.Int32("code", terr.Code),
+		zap.Any("details", terr.Details),
+		zap.Error(err),
+	)
+
+	return terr
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+)
+
+type mempoolAPIService struct {
+	client client.ZcoinClient
+	znodes *mapper.ZnodeCache
+	logger *zap.Logger
+}
+
+// NewMempoolAPIService creates a new service to answer mempool queries
+func NewMempoolAPIService(client client.ZcoinClient, znodes *mapper.ZnodeCache, logger *zap.Logger) server.MempoolAPIServicer {
+	return &mempoolAPIService{
+		client: client,
+		znodes: znodes,
+		logger: logger,
+	}
+}
+
+// Mempool returns the identifiers of the transactions in the node's mempool
+func (mempool *mempoolAPIService) Mempool(ctx context.Context, request *types.NetworkRequest) (*types.MempoolResponse, *types.Error) {
+	terr := ValidateNetworkIdentifier(ctx, mempool.client, request.NetworkIdentifier)
+	if terr != nil {
+		return nil, terr
+	}
+
+	hashes, err := mempool.client.GetRawMempool(ctx)
+	if err != nil {
+		return nil, logCause(ctx, mempool.logger, nodeError(err, ErrUnableToGetTxns), err)
+	}
+
+	identifiers := make([]*types.TransactionIdentifier, 0, len(hashes))
+	for _, hash := range hashes {
+		identifiers = append(identifiers, &types.TransactionIdentifier{
+			Hash: hash,
+		})
+	}
+
+	return &types.MempoolResponse{
+		TransactionIdentifiers: identifiers,
+	}, nil
+}
+
+// MempoolTransaction returns a transaction of the mempool mapped like the
+// transactions of a block
+func (mempool *mempoolAPIService) MempoolTransaction(
+	ctx context.Context,
+	request *types.MempoolTransactionRequest,
+) (*types.MempoolTransactionResponse, *types.Error) {
+	terr := ValidateNetworkIdentifier(ctx, mempool.client, request.NetworkIdentifier)
+	if terr != nil {
+		return nil, terr
+	}
+
+	tx, err := mempool.client.GetRawTransaction(ctx, request.TransactionIdentifier.Hash)
+	if err != nil {
+		return nil, logCause(ctx, mempool.logger, nodeError(err, ErrUnableToGetTxns), err)
+	}
+	if tx.BlockHash != "" {
+		return nil, withDetails(ErrUnableToGetTxns, map[string]interface{}{
+			"transaction_hash": tx.Txid,
+			"block_hash":       tx.BlockHash,
+		})
+	}
+
+	// a mempool transaction is annotated with the Znodes of the current tip
+	status, err := mempool.client.GetStatus(ctx)
+	if err != nil {
+		return nil, logCause(ctx, mempool.logger, nodeError(err, ErrUnableToGetNodeStatus), err)
+	}
+	znodes, err := mempool.znodes.Get(ctx, mempool.client, &types.BlockIdentifier{
+		Index: int64(status.Blocks),
+		Hash:  status.BestBlockHash,
+	})
+	if err != nil {
+		return nil, logCause(ctx, mempool.logger, znodeError(err), err)
+	}
+
+	transaction, err := mapper.Transaction(
+		ctx,
+		mempool.client,
+		tx,
+		mapper.TransactionCache{},
+		znodes,
+		mempool.logger,
+	)
+	if err != nil {
+		return nil, logCause(ctx, mempool.logger, ErrUnableToGetTxns, err)
+	}
+
+	return &types.MempoolTransactionResponse{
+		Transaction: transaction,
+	}, nil
+}
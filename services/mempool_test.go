@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+const (
+	alice = "TAmVDhmNuC4Qsb3xkwYmzGpBkbtaQUGusx"
+	bob   = "TBjJvmhWAT1uLDuA5FU6DvGWpBjt8TBXsu"
+)
+
+var network = &types.NetworkIdentifier{Blockchain: "Zcoin", Network: client.REGTEST}
+
+// newChain serves a node with height blocks mined to alice
+func newChain(height int) (*zcoindtest.Node, *zcoindtest.Server) {
+	node := zcoindtest.NewNode()
+	node.SetMinerAddress(alice)
+	for i := 0; i < height; i++ {
+		node.Mine()
+	}
+
+	return node, zcoindtest.NewServer(node)
+}
+
+func TestMempoolTransaction(t *testing.T) {
+	node, stub := newChain(2)
+	defer stub.Close()
+	funds := node.BlockAt(1).Tx[0]
+	pending := zcoindtest.Transaction(
+		[]client.Vin{zcoindtest.Input(&funds, 0)},
+		zcoindtest.Output(bob, 49.5),
+	)
+	node.AddToMempool(pending)
+	service := NewMempoolAPIService(client.NewZcoinClient(stub.Config(), zap.NewNop()), mapper.NewZnodeCache(), zap.NewNop())
+	ctx := context.Background()
+
+	mempool, terr := service.Mempool(ctx, &types.NetworkRequest{NetworkIdentifier: network})
+	if terr != nil {
+		t.Fatal(terr)
+	}
+	if len(mempool.TransactionIdentifiers) != 1 || mempool.TransactionIdentifiers[0].Hash != pending.Txid {
+		t.Fatalf("got %v, want %s", types.PrettyPrintStruct(mempool.TransactionIdentifiers), pending.Txid)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		want *types.Error
+	}{
+		{"pending", pending.Txid, nil},
+		{"mined", funds.Txid, ErrUnableToGetTxns},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, terr := service.MempoolTransaction(ctx, &types.MempoolTransactionRequest{
+				NetworkIdentifier:     network,
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: test.hash},
+			})
+			if test.want != nil {
+				if terr == nil || terr.Code != test.want.Code {
+					t.Errorf("got %+v, want %s", terr, test.want.Message)
+				}
+				return
+			}
+			if terr != nil {
+				t.Fatal(terr)
+			}
+
+			// the input, the output and the fee
+			transaction := response.Transaction
+			if fee := transaction.Metadata["fee"]; len(transaction.Operations) != 3 || fee != float64(50000000) {
+				t.Errorf("got %d operations and a fee of %v, want 3 and 50000000", len(transaction.Operations), fee)
+			}
+		})
+	}
+}
@@ -11,20 +11,58 @@ import (
 
 	"github.com/coinbase/rosetta-sdk-go/server"
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
 	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
 )
 
 type networkAPIService struct {
-	client client.ZcoinClient
+	client  client.ZcoinClient
+	blocks  *mapper.BlockResponseCache
+	genesis *types.BlockIdentifier
+	logger  *zap.Logger
 }
 
-// NewNetworkAPIService creates a new service to communicate about Network related topics
-func NewNetworkAPIService(client client.ZcoinClient) server.NetworkAPIServicer {
+// NewNetworkAPIService creates a new service to communicate about Network related topics,
+// genesis is the verified genesis block of the node
+func NewNetworkAPIService(
+	client client.ZcoinClient,
+	blocks *mapper.BlockResponseCache,
+	genesis *types.BlockIdentifier,
+	logger *zap.Logger,
+) server.NetworkAPIServicer {
 	return &networkAPIService{
-		client: client,
+		client:  client,
+		blocks:  blocks,
+		genesis: genesis,
+		logger:  logger,
 	}
 }
 
+// VerifyGenesisBlock fetches the genesis block of the node and checks it is
+// the one of the configured network, a node on another chain is an error
+func VerifyGenesisBlock(ctx context.Context, zcoinClient client.ZcoinClient) (*types.BlockIdentifier, error) {
+	network := zcoinClient.GetConfig().NetworkIdentifier.Network
+	expected, ok := client.GenesisHash(network)
+	if !ok {
+		return nil, errors.Errorf("unknown network %q", network)
+	}
+
+	genesisBlock, err := zcoinClient.GetBlock(ctx, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get genesis block")
+	}
+	if genesisBlock.Hash != expected {
+		return nil, errors.Errorf("node genesis block %s is not the %s genesis block %s", genesisBlock.Hash, network, expected)
+	}
+
+	return &types.BlockIdentifier{
+		Index: 0,
+		Hash:  genesisBlock.Hash,
+	}, nil
+}
+
 func (network *networkAPIService) NetworkList(context.Context, *types.MetadataRequest) (*types.NetworkListResponse, *types.Error) {
 	cfg := network.client.GetConfig()
 	return &types.NetworkListResponse{
@@ -61,10 +99,9 @@ func (network *networkAPIService) NetworkOptions(
 					Successful: false,
 				},
 			},
-			OperationTypes: []string{
-				client.Transfer,
-			},
-			Errors: ErrorList,
+			OperationTypes:          client.OperationTypes,
+			Errors:                  ErrorList,
+			HistoricalBalanceLookup: true,
 		},
 	}, nil
 }
@@ -99,32 +136,30 @@ func (network *networkAPIService) NetworkStatus(
 
 	status, err := network.client.GetStatus(ctx)
 	if err != nil {
-		return nil, ErrUnableToGetNodeStatus
+		return nil, logCause(ctx, network.logger, nodeError(err, ErrUnableToGetNodeStatus), err)
 	}
 
-	height := int64(status.Blocks)
-
-	bestBlock, err := network.client.GetBlock(ctx, height)
-	if err != nil {
-		return nil, ErrUnableToGetNodeStatus
+	currentBlock := &types.BlockIdentifier{
+		Index: int64(status.Blocks),
+		Hash:  status.BestBlockHash,
 	}
 
-	genesisBlock, err := network.client.GetBlock(ctx, 0)
-	if err != nil {
-		return nil, ErrUnableToGetNodeStatus
+	var currentTimestamp int64
+	if bestBlock := network.blocks.Get(currentBlock.Hash); bestBlock != nil {
+		currentTimestamp = bestBlock.Timestamp
+	} else {
+		bestBlock, err := network.client.GetBlock(ctx, currentBlock.Index)
+		if err != nil {
+			return nil, logCause(ctx, network.logger, nodeError(err, ErrUnableToGetNodeStatus), err)
+		}
+		currentTimestamp = bestBlock.Time * 1000 // ms
 	}
 
 	resp := &types.NetworkStatusResponse{
-		CurrentBlockIdentifier: &types.BlockIdentifier{
-			Index: height,
-			Hash:  bestBlock.Hash,
-		},
-		CurrentBlockTimestamp: bestBlock.Time * 1000, // ms
-		GenesisBlockIdentifier: &types.BlockIdentifier{
-			Index: 0,
-			Hash:  genesisBlock.Hash,
-		},
-		Peers: nil,
+		CurrentBlockIdentifier: currentBlock,
+		CurrentBlockTimestamp:  currentTimestamp,
+		GenesisBlockIdentifier: network.genesis,
+		Peers:                  nil,
 	}
 
 	return resp, nil
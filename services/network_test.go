@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/mapper"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+func TestVerifyGenesisBlock(t *testing.T) {
+	node, stub := newChain(1)
+	defer stub.Close()
+
+	tests := []struct {
+		network string
+		wantErr bool
+	}{
+		{client.REGTEST, false},
+		{client.MAINNET, true},
+		{"signet", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.network, func(t *testing.T) {
+			cfg := stub.Config()
+			cfg.NetworkIdentifier.Network = test.network
+			genesis, err := VerifyGenesisBlock(context.Background(), client.NewZcoinClient(cfg, zap.NewNop()))
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("regtest node accepted as a %s node", test.network)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if genesis.Index != 0 || genesis.Hash != node.BlockAt(0).Hash {
+				t.Errorf("got genesis %+v, want %s", genesis, node.BlockAt(0).Hash)
+			}
+		})
+	}
+}
+
+func TestNodeUnavailable(t *testing.T) {
+	down := zcoindtest.NewServer(zcoindtest.NewNode())
+	down.Close()
+	service := NewNetworkAPIService(client.NewZcoinClient(down.Config(), zap.NewNop()), mapper.NewBlockResponseCache(0, 0), nil, zap.NewNop())
+
+	_, terr := service.NetworkStatus(context.Background(), &types.NetworkRequest{NetworkIdentifier: network})
+	if terr == nil || terr.Code != ErrNodeUnavailable.Code || !terr.Retriable {
+		t.Errorf("got %+v, want a retriable %s", terr, ErrNodeUnavailable.Message)
+	}
+}
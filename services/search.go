@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+)
+
+// Page sizes of the search and events endpoints
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// pageLimit applies the default and maximum page sizes to a requested limit
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+
+	return limit
+}
+
+// SearchTransactionsRequest searches the indexed transactions, every field
+// but the network identifier is an optional filter. Cursor resumes a previous
+// search and is taken from its response.
+type SearchTransactionsRequest struct {
+	NetworkIdentifier *types.NetworkIdentifier `json:"network_identifier"`
+	AccountIdentifier *types.AccountIdentifier `json:"account_identifier,omitempty"`
+	Currency          *types.Currency          `json:"currency,omitempty"`
+	Type              string                   `json:"type,omitempty"`
+	Status            string                   `json:"status,omitempty"`
+	MinBlock          *int64                   `json:"min_block,omitempty"`
+	MaxBlock          *int64                   `json:"max_block,omitempty"`
+	Cursor            string                   `json:"cursor,omitempty"`
+	Limit             int                      `json:"limit,omitempty"`
+}
+
+// SearchTransactionsResponse contains a page of matching transactions in chain
+// order, NextCursor is only set when more transactions match
+type SearchTransactionsResponse struct {
+	Transactions []*repository.BlockTransaction `json:"transactions"`
+	NextCursor   string                         `json:"next_cursor,omitempty"`
+}
+
+// SearchAPIServicer defines the api actions for the search endpoints, they are
+// not part of the Rosetta specification the SDK implements
+type SearchAPIServicer interface {
+	SearchTransactions(context.Context, *SearchTransactionsRequest) (*SearchTransactionsResponse, *types.Error)
+}
+
+type searchAPIService struct {
+	client client.ZcoinClient
+	blocks *repository.BlockProvider
+	logger *zap.Logger
+}
+
+// NewSearchAPIService creates a new service searching the indexed transactions
+func NewSearchAPIService(client client.ZcoinClient, blocks *repository.BlockProvider, logger *zap.Logger) SearchAPIServicer {
+	return &searchAPIService{
+		client: client,
+		blocks: blocks,
+		logger: logger,
+	}
+}
+
+// SearchTransactions returns the indexed transactions matching the request
+func (search *searchAPIService) SearchTransactions(
+	ctx context.Context,
+	request *SearchTransactionsRequest,
+) (*SearchTransactionsResponse, *types.Error) {
+	terr := ValidateNetworkIdentifier(ctx, search.client, request.NetworkIdentifier)
+	if terr != nil {
+		return nil, terr
+	}
+
+	filter := &repository.TransactionFilter{
+		Currency: request.Currency,
+		Type:     request.Type,
+		Status:   request.Status,
+		MinBlock: request.MinBlock,
+		MaxBlock: request.MaxBlock,
+	}
+	if request.AccountIdentifier != nil {
+		if request.AccountIdentifier.SubAccount != nil {
+			return nil, ErrInvalidAccountAddress
+		}
+		filter.Address = request.AccountIdentifier.Address
+	}
+
+	transactions, next, err := search.blocks.SearchTransactions(filter, request.Cursor, pageLimit(request.Limit))
+	if err == repository.ErrInvalidCursor {
+		return nil, ErrInvalidSearchCursor
+	}
+	if err != nil {
+		return nil, logCause(ctx, search.logger, ErrUnableToSearchTxns, err)
+	}
+	if transactions == nil {
+		transactions = []*repository.BlockTransaction{}
+	}
+
+	return &SearchTransactionsResponse{
+		Transactions: transactions,
+		NextCursor:   next,
+	}, nil
+}
+
+// SearchAPIController binds the search endpoints to a SearchAPIServicer the
+// same way the SDK controllers bind the Rosetta endpoints
+type SearchAPIController struct {
+	service  SearchAPIServicer
+	asserter *asserter.Asserter
+}
+
+// NewSearchAPIController creates a controller for the search endpoints
+func NewSearchAPIController(s SearchAPIServicer, asserter *asserter.Asserter) server.Router {
+	return &SearchAPIController{
+		service:  s,
+		asserter: asserter,
+	}
+}
+
+// Routes returns all of the api routes for the SearchAPIController
+func (c *SearchAPIController) Routes() server.Routes {
+	return server.Routes{
+		{
+			Name:        "SearchTransactions",
+			Method:      http.MethodPost,
+			Pattern:     "/search/transactions",
+			HandlerFunc: c.SearchTransactions,
+		},
+	}
+}
+
+// SearchTransactions - Search for Transactions
+func (c *SearchAPIController) SearchTransactions(w http.ResponseWriter, r *http.Request) {
+	request := &SearchTransactionsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	if err := c.assertSearchTransactionsRequest(request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	result, serviceErr := c.service.SearchTransactions(r.Context(), request)
+	if serviceErr != nil {
+		server.EncodeJSONResponse(serviceErr, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	server.EncodeJSONResponse(result, http.StatusOK, w)
+}
+
+func (c *SearchAPIController) assertSearchTransactionsRequest(request *SearchTransactionsRequest) error {
+	if err := c.asserter.ValidSupportedNetwork(request.NetworkIdentifier); err != nil {
+		return err
+	}
+	if request.AccountIdentifier != nil {
+		if err := asserter.AccountIdentifier(request.AccountIdentifier); err != nil {
+			return err
+		}
+	}
+	if request.Type != "" {
+		if err := c.asserter.OperationType(request.Type); err != nil {
+			return err
+		}
+	}
+	if request.Status != "" && request.Status != client.StatusSuccess && request.Status != client.StatusFail {
+		return errors.Errorf("Operation.Status %s is invalid", request.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/webhook"
+)
+
+// WebhooksAddRequest registers a URL called for the deposits to an account
+// once they reach the given number of confirmations, a secret is generated
+// when none is provided
+type WebhooksAddRequest struct {
+	NetworkIdentifier *types.NetworkIdentifier `json:"network_identifier"`
+	AccountIdentifier *types.AccountIdentifier `json:"account_identifier"`
+	URL               string                   `json:"url"`
+	Confirmations     int64                    `json:"confirmations"`
+	Secret            string                   `json:"secret,omitempty"`
+}
+
+// WebhooksAddResponse contains the registered watch along with the secret
+// signing its deliveries
+type WebhooksAddResponse struct {
+	Watch *repository.Watch `json:"watch"`
+}
+
+// WebhooksRemoveRequest unregisters the watch with the given id
+type WebhooksRemoveRequest struct {
+	NetworkIdentifier *types.NetworkIdentifier `json:"network_identifier"`
+	ID                string                   `json:"id"`
+}
+
+// WebhooksRemoveResponse contains the removed watch
+type WebhooksRemoveResponse struct {
+	Watch *repository.Watch `json:"watch"`
+}
+
+// WebhooksListRequest lists the registered watches
+type WebhooksListRequest struct {
+	NetworkIdentifier *types.NetworkIdentifier `json:"network_identifier"`
+}
+
+// WebhooksListResponse contains the registered watches without their secrets
+type WebhooksListResponse struct {
+	Watches []*repository.Watch `json:"watches"`
+}
+
+// WebhooksAPIServicer defines the api actions for the webhooks endpoints
+type WebhooksAPIServicer interface {
+	WebhooksAdd(context.Context, *WebhooksAddRequest) (*WebhooksAddResponse, *types.Error)
+	WebhooksRemove(context.Context, *WebhooksRemoveRequest) (*WebhooksRemoveResponse, *types.Error)
+	WebhooksList(context.Context, *WebhooksListRequest) (*WebhooksListResponse, *types.Error)
+}
+
+type webhooksAPIService struct {
+	client   client.ZcoinClient
+	webhooks *repository.WebhookProvider
+	logger   *zap.Logger
+}
+
+// NewWebhooksAPIService creates a new service managing the deposit webhooks
+func NewWebhooksAPIService(client client.ZcoinClient, webhooks *repository.WebhookProvider, logger *zap.Logger) WebhooksAPIServicer {
+	return &webhooksAPIService{
+		client:   client,
+		webhooks: webhooks,
+		logger:   logger,
+	}
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// invalidWebhook reports the reason along with ErrInvalidWebhook
+func invalidWebhook(reason string) *types.Error {
+	return withDetails(ErrInvalidWebhook, map[string]interface{}{
+		"reason": reason,
+	})
+}
+
+// WebhooksAdd registers a new watch
+func (webhooks *webhooksAPIService) WebhooksAdd(
+	ctx context.Context,
+	request *WebhooksAddRequest,
+) (*WebhooksAddResponse, *types.Error) {
+	terr := ValidateNetworkIdentifier(ctx, webhooks.client, request.NetworkIdentifier)
+	if terr != nil {
+		return nil, terr
+	}
+
+	if request.AccountIdentifier.SubAccount != nil || client.IsPseudoAddress(request.AccountIdentifier.Address) {
+		return nil, ErrInvalidAccountAddress
+	}
+	if err := webhook.CheckURL(webhooks.client.GetConfig().Webhooks, request.URL); err != nil {
+		return nil, invalidWebhook(err.Error())
+	}
+	if request.Confirmations < 1 {
+		return nil, invalidWebhook("confirmations must be at least 1")
+	}
+
+	watch := &repository.Watch{
+		Address:       request.AccountIdentifier.Address,
+		URL:           request.URL,
+		Secret:        request.Secret,
+		Confirmations: request.Confirmations,
+	}
+	var err error
+	if watch.ID, err = randomHex(16); err != nil {
+		return nil, logCause(ctx, webhooks.logger, ErrUnableToUpdateWebhooks, err)
+	}
+	if watch.Secret == "" {
+		if watch.Secret, err = randomHex(32); err != nil {
+			return nil, logCause(ctx, webhooks.logger, ErrUnableToUpdateWebhooks, err)
+		}
+	}
+
+	if err := webhooks.webhooks.AddWatch(watch); err != nil {
+		return nil, logCause(ctx, webhooks.logger, ErrUnableToUpdateWebhooks, err)
+	}
+
+	return &WebhooksAddResponse{
+		Watch: watch,
+	}, nil
+}
+
+// WebhooksRemove unregisters a watch, its pending deliveries are dropped
+func (webhooks *webhooksAPIService) WebhooksRemove(
+	ctx context.Context,
+	request *WebhooksRemoveRequest,
+) (*WebhooksRemoveResponse, *types.Error) {
+	terr := ValidateNetworkIdentifier(ctx, webhooks.client, request.NetworkIdentifier)
+	if terr != nil {
+		return nil, terr
+	}
+
+	watch, err := webhooks.webhooks.RemoveWatch(request.ID)
+	if err == repository.ErrNotFound {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, logCause(ctx, webhooks.logger, ErrUnableToUpdateWebhooks, err)
+	}
+	watch.Secret = ""
+
+	return &WebhooksRemoveResponse{
+		Watch: watch,
+	}, nil
+}
+
+// WebhooksList returns the registered watches
+func (webhooks *webhooksAPIService) WebhooksList(
+	ctx context.Context,
+	request *WebhooksListRequest,
+) (*WebhooksListResponse, *types.Error) {
+	terr := ValidateNetworkIdentifier(ctx, webhooks.client, request.NetworkIdentifier)
+	if terr != nil {
+		return nil, terr
+	}
+
+	watches, err := webhooks.webhooks.ListWatches()
+	if err != nil {
+		return nil, logCause(ctx, webhooks.logger, ErrUnableToUpdateWebhooks, err)
+	}
+	for _, watch := range watches {
+		watch.Secret = ""
+	}
+	if watches == nil {
+		watches = []*repository.Watch{}
+	}
+
+	return &WebhooksListResponse{
+		Watches: watches,
+	}, nil
+}
+
+// WebhooksAPIController binds the webhooks endpoints to a WebhooksAPIServicer
+type WebhooksAPIController struct {
+	service  WebhooksAPIServicer
+	asserter *asserter.Asserter
+}
+
+// NewWebhooksAPIController creates a controller for the webhooks endpoints
+func NewWebhooksAPIController(s WebhooksAPIServicer, asserter *asserter.Asserter) server.Router {
+	return &WebhooksAPIController{
+		service:  s,
+		asserter: asserter,
+	}
+}
+
+// Routes returns all of the api routes for the WebhooksAPIController
+func (c *WebhooksAPIController) Routes() server.Routes {
+	return server.Routes{
+		{
+			Name:        "WebhooksAdd",
+			Method:      http.MethodPost,
+			Pattern:     "/webhooks/add",
+			HandlerFunc: c.WebhooksAdd,
+		},
+		{
+			Name:        "WebhooksRemove",
+			Method:      http.MethodPost,
+			Pattern:     "/webhooks/remove",
+			HandlerFunc: c.WebhooksRemove,
+		},
+		{
+			Name:        "WebhooksList",
+			Method:      http.MethodPost,
+			Pattern:     "/webhooks/list",
+			HandlerFunc: c.WebhooksList,
+		},
+	}
+}
+
+// WebhooksAdd - Register a deposit webhook
+func (c *WebhooksAPIController) WebhooksAdd(w http.ResponseWriter, r *http.Request) {
+	request := &WebhooksAddRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	if err := c.assertWebhooksAddRequest(request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	result, serviceErr := c.service.WebhooksAdd(r.Context(), request)
+	if serviceErr != nil {
+		server.EncodeJSONResponse(serviceErr, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	server.EncodeJSONResponse(result, http.StatusOK, w)
+}
+
+// WebhooksRemove - Unregister a deposit webhook
+func (c *WebhooksAPIController) WebhooksRemove(w http.ResponseWriter, r *http.Request) {
+	request := &WebhooksRemoveRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	if err := c.asserter.ValidSupportedNetwork(request.NetworkIdentifier); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	result, serviceErr := c.service.WebhooksRemove(r.Context(), request)
+	if serviceErr != nil {
+		server.EncodeJSONResponse(serviceErr, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	server.EncodeJSONResponse(result, http.StatusOK, w)
+}
+
+// WebhooksList - List the deposit webhooks
+func (c *WebhooksAPIController) WebhooksList(w http.ResponseWriter, r *http.Request) {
+	request := &WebhooksListRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	if err := c.asserter.ValidSupportedNetwork(request.NetworkIdentifier); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	result, serviceErr := c.service.WebhooksList(r.Context(), request)
+	if serviceErr != nil {
+		server.EncodeJSONResponse(serviceErr, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	server.EncodeJSONResponse(result, http.StatusOK, w)
+}
+
+func (c *WebhooksAPIController) assertWebhooksAddRequest(request *WebhooksAddRequest) error {
+	if err := c.asserter.ValidSupportedNetwork(request.NetworkIdentifier); err != nil {
+		return err
+	}
+
+	return asserter.AccountIdentifier(request.AccountIdentifier)
+}
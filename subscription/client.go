@@ -0,0 +1,137 @@
+package subscription
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait bounds the time a single write may take
+	writeWait = 10 * time.Second
+	// pongWait is how long a client may stay silent before it is dropped
+	pongWait = 60 * time.Second
+	// pingPeriod keeps idle connections alive, it must be below pongWait
+	pingPeriod = pongWait / 2
+	// maxRequestSize bounds the size of client requests
+	maxRequestSize = 64 * 1024
+	// MaxSubscriptions bounds the number of subscriptions of a client
+	MaxSubscriptions = 32
+)
+
+// client is a WebSocket connection along with its subscriptions, every write
+// goes through the outgoing buffer so that only writeLoop writes to the
+// connection
+type client struct {
+	conn     *websocket.Conn
+	outgoing chan []byte
+	done     chan struct{}
+
+	closeOnce    sync.Once
+	closeMessage []byte
+
+	mu     sync.Mutex
+	subs   map[string]*Subscription
+	nextID int
+}
+
+func newClient(conn *websocket.Conn, bufferSize int) *client {
+	return &client{
+		conn:     conn,
+		outgoing: make(chan []byte, bufferSize),
+		done:     make(chan struct{}),
+		subs:     make(map[string]*Subscription),
+	}
+}
+
+// send queues a message, a client whose buffer is full is disconnected
+func (c *client) send(message []byte) {
+	select {
+	case <-c.done:
+	case c.outgoing <- message:
+	default:
+		c.close(websocket.ClosePolicyViolation, "slow consumer, notifications were dropped")
+	}
+}
+
+// close makes writeLoop send a close frame and close the connection
+func (c *client) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeMessage = websocket.FormatCloseMessage(code, reason)
+		close(c.done)
+	})
+}
+
+func (c *client) subscribe(topic string, filter *Filter) (*Subscription, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.subs) >= MaxSubscriptions {
+		return nil, false
+	}
+
+	c.nextID++
+	subscription := &Subscription{
+		ID:     strconv.Itoa(c.nextID),
+		Topic:  topic,
+		Filter: filter,
+	}
+	c.subs[subscription.ID] = subscription
+	return subscription, true
+}
+
+func (c *client) unsubscribe(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.subs[id]
+	delete(c.subs, id)
+	return ok
+}
+
+func (c *client) subscriptions(topic string) []*Subscription {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var subscriptions []*Subscription
+	for _, subscription := range c.subs {
+		if subscription.Topic == topic {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	return subscriptions
+}
+
+// writeLoop writes the queued messages and keeps the connection alive until
+// the client is closed
+func (c *client) writeLoop() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message := <-c.outgoing:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage, c.closeMessage, time.Now().Add(writeWait))
+			return
+		}
+	}
+}
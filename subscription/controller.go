@@ -0,0 +1,151 @@
+package subscription
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/logging"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/services"
+)
+
+// Controller upgrades requests to WebSocket connections and serves the
+// subscriptions of every connected client from the hub
+type Controller struct {
+	hub      *Hub
+	asserter *asserter.Asserter
+	upgrader websocket.Upgrader
+}
+
+// NewController creates a controller for the subscription endpoint
+func NewController(hub *Hub, asserter *asserter.Asserter) server.Router {
+	return &Controller{
+		hub:      hub,
+		asserter: asserter,
+		upgrader: websocket.Upgrader{
+			// the Rosetta router allows every origin as well
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Routes returns all of the api routes for the Controller
+func (c *Controller) Routes() server.Routes {
+	return server.Routes{
+		{
+			Name:        "Subscribe",
+			Method:      http.MethodGet,
+			Pattern:     "/ws",
+			HandlerFunc: c.Subscribe,
+		},
+	}
+}
+
+// Subscribe - Open a WebSocket connection and serve its requests until it closes
+func (c *Controller) Subscribe(w http.ResponseWriter, r *http.Request) {
+	conn, err := c.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already replied with an error
+		return
+	}
+
+	logger := logging.FromContext(r.Context(), c.hub.logger)
+	cl := newClient(conn, c.hub.bufferSize)
+	c.hub.register(cl)
+	defer c.hub.unregister(cl)
+	go cl.writeLoop()
+
+	conn.SetReadLimit(maxRequestSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Error("connection failed", zap.String("remote_addr", r.RemoteAddr), zap.Error(err))
+			}
+			cl.close(websocket.CloseNormalClosure, "")
+			return
+		}
+
+		response, err := json.Marshal(c.handle(cl, message))
+		if err != nil {
+			logger.Error("unable to encode response", zap.Error(err))
+			continue
+		}
+		cl.send(response)
+	}
+}
+
+func (c *Controller) handle(cl *client, message []byte) *Response {
+	request := &Request{}
+	if err := json.Unmarshal(message, request); err != nil {
+		return &Response{Error: invalidRequest(err.Error())}
+	}
+
+	response := &Response{ID: request.ID}
+	switch request.Method {
+	case MethodSubscribe:
+		if terr := c.validateSubscription(request); terr != nil {
+			response.Error = terr
+			break
+		}
+		subscription, ok := cl.subscribe(request.Topic, request.Filter)
+		if !ok {
+			response.Error = invalidRequest("too many subscriptions")
+			break
+		}
+		response.Subscription = subscription.ID
+
+	case MethodUnsubscribe:
+		if !cl.unsubscribe(request.Subscription) {
+			response.Error = invalidRequest("unknown subscription " + request.Subscription)
+			break
+		}
+		response.Subscription = request.Subscription
+
+	default:
+		response.Error = invalidRequest("unknown method " + request.Method)
+	}
+
+	return response
+}
+
+func (c *Controller) validateSubscription(request *Request) *types.Error {
+	if err := c.asserter.ValidSupportedNetwork(request.NetworkIdentifier); err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	switch request.Topic {
+	case TopicBlocks, TopicReorgs, TopicMempool:
+		if request.Filter != nil {
+			return invalidRequest("topic " + request.Topic + " does not support filters")
+		}
+	case TopicAddress:
+		if request.Filter == nil || len(request.Filter.Addresses) == 0 {
+			return invalidRequest("topic address requires a filter with addresses")
+		}
+	default:
+		return invalidRequest("unknown topic " + request.Topic)
+	}
+
+	return nil
+}
+
+// invalidRequest reports the reason along with ErrInvalidSubscription
+func invalidRequest(reason string) *types.Error {
+	terr := *services.ErrInvalidSubscription
+	terr.Details = map[string]interface{}{
+		"reason": reason,
+	}
+
+	return &terr
+}
@@ -0,0 +1,162 @@
+package subscription
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// DefaultBufferSize is used when no buffer size has been configured
+const DefaultBufferSize = 256
+
+// Subscription is a topic a client subscribed to along with its filter
+type Subscription struct {
+	ID     string
+	Topic  string
+	Filter *Filter
+}
+
+// Hub receives the indexer changes and fans them out to the subscribed
+// clients. Every client has a buffer of pending notifications, a client that
+// lets it fill up is disconnected instead of slowing the indexer down.
+type Hub struct {
+	mu         sync.RWMutex
+	clients    map[*client]struct{}
+	bufferSize int
+	logger     *zap.Logger
+}
+
+// NewHub creates a hub buffering up to bufferSize notifications per client
+func NewHub(bufferSize int, logger *zap.Logger) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &Hub{
+		clients:    make(map[*client]struct{}),
+		bufferSize: bufferSize,
+		logger:     logger,
+	}
+}
+
+func (hub *Hub) register(c *client) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.clients[c] = struct{}{}
+}
+
+func (hub *Hub) unregister(c *client) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	delete(hub.clients, c)
+}
+
+// Close disconnects every client, they are told the gateway is going away
+func (hub *Hub) Close() {
+	for _, c := range hub.connected() {
+		c.close(websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+// connected returns the clients connected when it is called
+func (hub *Hub) connected() []*client {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+
+	clients := make([]*client, 0, len(hub.clients))
+	for c := range hub.clients {
+		clients = append(clients, c)
+	}
+
+	return clients
+}
+
+// publish sends the data returned for every subscription to the topic, a nil
+// data skips the subscription. The notifications are encoded without holding
+// the lock so clients can connect and disconnect meanwhile.
+func (hub *Hub) publish(topic string, data func(*Subscription) interface{}) {
+	for _, c := range hub.connected() {
+		for _, subscription := range c.subscriptions(topic) {
+			payload := data(subscription)
+			if payload == nil {
+				continue
+			}
+
+			message, err := json.Marshal(&Notification{
+				Subscription: subscription.ID,
+				Topic:        topic,
+				Data:         payload,
+			})
+			if err != nil {
+				hub.logger.Error("unable to encode notification", zap.String("topic", topic), zap.Error(err))
+				continue
+			}
+			c.send(message)
+		}
+	}
+}
+
+// publishActivity notifies the address subscriptions of the operations of
+// every transaction in the block
+func (hub *Hub) publishActivity(block *types.Block, removed bool) {
+	for _, transaction := range block.Transactions {
+		hub.publish(TopicAddress, func(subscription *Subscription) interface{} {
+			var operations []*types.Operation
+			for _, op := range transaction.Operations {
+				if subscription.Filter.matches(op) {
+					operations = append(operations, op)
+				}
+			}
+			if len(operations) == 0 {
+				return nil
+			}
+
+			return &AddressData{
+				Removed:               removed,
+				BlockIdentifier:       block.BlockIdentifier,
+				TransactionIdentifier: transaction.TransactionIdentifier,
+				Operations:            operations,
+			}
+		})
+	}
+}
+
+// BlockAdded notifies the blocks and address subscriptions
+func (hub *Hub) BlockAdded(block *types.Block) {
+	hub.publish(TopicBlocks, func(*Subscription) interface{} {
+		return newBlockData(block)
+	})
+	hub.publishActivity(block, false)
+}
+
+// BlockRemoved notifies the reorgs and address subscriptions
+func (hub *Hub) BlockRemoved(block *types.Block) {
+	hub.publish(TopicReorgs, func(*Subscription) interface{} {
+		return newBlockData(block)
+	})
+	hub.publishActivity(block, true)
+}
+
+// TransactionAdded notifies the mempool subscriptions
+func (hub *Hub) TransactionAdded(hash string) {
+	hub.publishMempool(MempoolAdded, hash)
+}
+
+// TransactionRemoved notifies the mempool subscriptions
+func (hub *Hub) TransactionRemoved(hash string) {
+	hub.publishMempool(MempoolRemoved, hash)
+}
+
+func (hub *Hub) publishMempool(kind string, hash string) {
+	hub.publish(TopicMempool, func(*Subscription) interface{} {
+		return &MempoolData{
+			Type:                  kind,
+			TransactionIdentifier: &types.TransactionIdentifier{Hash: hash},
+		}
+	})
+}
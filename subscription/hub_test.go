@@ -0,0 +1,274 @@
+package subscription
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	zcoin "gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/services"
+)
+
+const (
+	alice = "TAmVDhmNuC4Qsb3xkwYmzGpBkbtaQUGusx"
+	bob   = "TBjJvmhWAT1uLDuA5FU6DvGWpBjt8TBXsu"
+)
+
+var network = &types.NetworkIdentifier{Blockchain: "Zcoin", Network: zcoin.REGTEST}
+
+// payment is a block with a transaction paying bob from alice
+func payment(index int64) *types.Block {
+	currency := &types.Currency{Symbol: "XZC", Decimals: 8}
+	return &types.Block{
+		BlockIdentifier:       &types.BlockIdentifier{Index: index, Hash: "block"},
+		ParentBlockIdentifier: &types.BlockIdentifier{Index: index - 1, Hash: "parent"},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "payment"},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                zcoin.Transfer,
+						Account:             &types.AccountIdentifier{Address: alice},
+						Amount:              &types.Amount{Value: "-10", Currency: currency},
+					},
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 1},
+						Type:                zcoin.Transfer,
+						Account:             &types.AccountIdentifier{Address: bob},
+						Amount:              &types.Amount{Value: "10", Currency: currency},
+					},
+				},
+			},
+		},
+	}
+}
+
+// pending returns the notifications queued for a client
+func pending(t *testing.T, c *client) []*Notification {
+	var notifications []*Notification
+	for {
+		select {
+		case message := <-c.outgoing:
+			notification := &Notification{}
+			if err := json.Unmarshal(message, notification); err != nil {
+				t.Fatal(err)
+			}
+			notifications = append(notifications, notification)
+		default:
+			return notifications
+		}
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	tests := []struct {
+		name    string
+		topic   string
+		filter  *Filter
+		publish func(*Hub)
+		want    []string
+	}{
+		{
+			name:    "added block",
+			topic:   TopicBlocks,
+			publish: func(hub *Hub) { hub.BlockAdded(payment(1)) },
+			want:    []string{TopicBlocks},
+		},
+		{
+			name:    "removed block is no new block",
+			topic:   TopicBlocks,
+			publish: func(hub *Hub) { hub.BlockRemoved(payment(1)) },
+		},
+		{
+			name:    "removed block",
+			topic:   TopicReorgs,
+			publish: func(hub *Hub) { hub.BlockRemoved(payment(1)) },
+			want:    []string{TopicReorgs},
+		},
+		{
+			name:  "mempool",
+			topic: TopicMempool,
+			publish: func(hub *Hub) {
+				hub.TransactionAdded("payment")
+				hub.TransactionRemoved("payment")
+			},
+			want: []string{TopicMempool, TopicMempool},
+		},
+		{
+			name:  "activity of an added and a removed block",
+			topic: TopicAddress,
+			filter: &Filter{
+				Addresses: []string{bob},
+			},
+			publish: func(hub *Hub) {
+				hub.BlockAdded(payment(1))
+				hub.BlockRemoved(payment(1))
+			},
+			want: []string{TopicAddress, TopicAddress},
+		},
+		{
+			name:  "activity of another address",
+			topic: TopicAddress,
+			filter: &Filter{
+				Addresses: []string{"TCpHbRrEyUVp7UdTbSYxRQbGSSLdAdCkyM"},
+			},
+			publish: func(hub *Hub) { hub.BlockAdded(payment(1)) },
+		},
+		{
+			name:  "activity of other operation types",
+			topic: TopicAddress,
+			filter: &Filter{
+				Addresses:      []string{bob},
+				OperationTypes: []string{zcoin.SigmaSpend},
+			},
+			publish: func(hub *Hub) { hub.BlockAdded(payment(1)) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := NewHub(0, zap.NewNop())
+			c := newClient(nil, DefaultBufferSize)
+			hub.register(c)
+			subscription, _ := c.subscribe(test.topic, test.filter)
+
+			test.publish(hub)
+			var got []string
+			for _, notification := range pending(t, c) {
+				if notification.Subscription != subscription.ID {
+					t.Errorf("got a notification for subscription %s, want %s", notification.Subscription, subscription.ID)
+				}
+				got = append(got, notification.Topic)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSlowConsumer(t *testing.T) {
+	hub := NewHub(1, zap.NewNop())
+	c := newClient(nil, hub.bufferSize)
+	hub.register(c)
+	c.subscribe(TopicMempool, nil)
+
+	hub.TransactionAdded("first")
+	select {
+	case <-c.done:
+		t.Fatal("client closed with room left in its buffer")
+	default:
+	}
+
+	hub.TransactionAdded("second")
+	select {
+	case <-c.done:
+	default:
+		t.Fatal("client kept with a full buffer")
+	}
+	if code := int(c.closeMessage[0])<<8 | int(c.closeMessage[1]); code != websocket.ClosePolicyViolation {
+		t.Errorf("closed with code %d, want %d", code, websocket.ClosePolicyViolation)
+	}
+}
+
+func TestController(t *testing.T) {
+	assert, err := asserter.NewServer(zcoin.OperationTypes, true, []*types.NetworkIdentifier{network})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := NewHub(0, zap.NewNop())
+	endpoint := httptest.NewServer(NewController(hub, assert).Routes()[0].HandlerFunc)
+	defer endpoint.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(endpoint.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	tests := []struct {
+		name    string
+		request *Request
+		want    string
+	}{
+		{
+			name:    "blocks",
+			request: &Request{Method: MethodSubscribe, NetworkIdentifier: network, Topic: TopicBlocks},
+			want:    "1",
+		},
+		{
+			name:    "unsubscribe",
+			request: &Request{Method: MethodUnsubscribe, Subscription: "1"},
+			want:    "1",
+		},
+		{
+			name:    "unknown subscription",
+			request: &Request{Method: MethodUnsubscribe, Subscription: "1"},
+		},
+		{
+			name:    "other network",
+			request: &Request{Method: MethodSubscribe, NetworkIdentifier: &types.NetworkIdentifier{Blockchain: "Zcoin", Network: zcoin.MAINNET}, Topic: TopicBlocks},
+		},
+		{
+			name:    "unknown topic",
+			request: &Request{Method: MethodSubscribe, NetworkIdentifier: network, Topic: "prices"},
+		},
+		{
+			name:    "filter on blocks",
+			request: &Request{Method: MethodSubscribe, NetworkIdentifier: network, Topic: TopicBlocks, Filter: &Filter{Addresses: []string{bob}}},
+		},
+		{
+			name:    "address without filter",
+			request: &Request{Method: MethodSubscribe, NetworkIdentifier: network, Topic: TopicAddress},
+		},
+		{
+			name:    "address",
+			request: &Request{Method: MethodSubscribe, NetworkIdentifier: network, Topic: TopicAddress, Filter: &Filter{Addresses: []string{bob}}},
+			want:    "2",
+		},
+		{
+			name:    "unknown method",
+			request: &Request{Method: "publish"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := conn.WriteJSON(test.request); err != nil {
+				t.Fatal(err)
+			}
+			response := &Response{}
+			if err := conn.ReadJSON(response); err != nil {
+				t.Fatal(err)
+			}
+
+			if test.want == "" {
+				if response.Error == nil || response.Error.Code != services.ErrInvalidSubscription.Code {
+					t.Errorf("got %+v, want %s", response, services.ErrInvalidSubscription.Message)
+				}
+				return
+			}
+			if response.Error != nil || response.Subscription != test.want {
+				t.Errorf("got %+v, want subscription %s", response, test.want)
+			}
+		})
+	}
+
+	hub.BlockAdded(payment(1))
+	notification := &Notification{}
+	if err := conn.ReadJSON(notification); err != nil {
+		t.Fatal(err)
+	}
+	if notification.Topic != TopicAddress || notification.Subscription != "2" {
+		t.Errorf("got a %s notification for subscription %s, want the address one", notification.Topic, notification.Subscription)
+	}
+}
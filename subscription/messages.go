@@ -0,0 +1,118 @@
+package subscription
+
+import (
+	"encoding/json"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// Topics clients can subscribe to. A client that does not keep up is
+// disconnected and recovers what it missed depending on the topic: blocks and
+// reorgs from /events/blocks after the last block it was notified of, address
+// activity from /search/transactions starting at that block, and the mempool
+// by fetching /mempool again.
+const (
+	TopicBlocks  = "blocks"
+	TopicReorgs  = "reorgs"
+	TopicMempool = "mempool"
+	TopicAddress = "address"
+)
+
+// Methods of the requests sent by clients
+const (
+	MethodSubscribe   = "subscribe"
+	MethodUnsubscribe = "unsubscribe"
+)
+
+// Kinds of mempool notifications
+const (
+	MempoolAdded   = "added"
+	MempoolRemoved = "removed"
+)
+
+// Request is sent by a client to subscribe to a topic or to cancel one of its
+// subscriptions, the response echoes its ID
+type Request struct {
+	ID                json.RawMessage          `json:"id,omitempty"`
+	Method            string                   `json:"method"`
+	NetworkIdentifier *types.NetworkIdentifier `json:"network_identifier,omitempty"`
+	Topic             string                   `json:"topic,omitempty"`
+	Filter            *Filter                  `json:"filter,omitempty"`
+	Subscription      string                   `json:"subscription,omitempty"`
+}
+
+// Response answers a request with the subscription created or cancelled
+type Response struct {
+	ID           json.RawMessage `json:"id,omitempty"`
+	Subscription string          `json:"subscription,omitempty"`
+	Error        *types.Error    `json:"error,omitempty"`
+}
+
+// Notification is pushed for every event matching a subscription
+type Notification struct {
+	Subscription string      `json:"subscription"`
+	Topic        string      `json:"topic"`
+	Data         interface{} `json:"data"`
+}
+
+// Filter selects the operations of an address subscription, an operation
+// matches when it satisfies every criterion that is set
+type Filter struct {
+	Addresses      []string        `json:"addresses"`
+	Currency       *types.Currency `json:"currency,omitempty"`
+	OperationTypes []string        `json:"operation_types,omitempty"`
+}
+
+func (filter *Filter) matches(op *types.Operation) bool {
+	if op.Account == nil || !contains(filter.Addresses, op.Account.Address) {
+		return false
+	}
+	if filter.Currency != nil && (op.Amount == nil || types.Hash(op.Amount.Currency) != types.Hash(filter.Currency)) {
+		return false
+	}
+	return len(filter.OperationTypes) == 0 || contains(filter.OperationTypes, op.Type)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BlockData is the data of blocks and reorgs notifications, clients fetch the
+// transactions from /block when they need them
+type BlockData struct {
+	BlockIdentifier       *types.BlockIdentifier `json:"block_identifier"`
+	ParentBlockIdentifier *types.BlockIdentifier `json:"parent_block_identifier"`
+	Timestamp             int64                  `json:"timestamp"`
+	TransactionCount      int                    `json:"transaction_count"`
+}
+
+func newBlockData(block *types.Block) *BlockData {
+	return &BlockData{
+		BlockIdentifier:       block.BlockIdentifier,
+		ParentBlockIdentifier: block.ParentBlockIdentifier,
+		Timestamp:             block.Timestamp,
+		TransactionCount:      len(block.Transactions),
+	}
+}
+
+// MempoolData is the data of mempool notifications
+type MempoolData struct {
+	Type                  string                       `json:"type"`
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+}
+
+// AddressData is the data of address notifications, it carries the matching
+// operations of a transaction. Removed is set when the block including the
+// transaction has been orphaned.
+type AddressData struct {
+	Removed               bool                         `json:"removed"`
+	BlockIdentifier       *types.BlockIdentifier       `json:"block_identifier"`
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+	Operations            []*types.Operation           `json:"operations"`
+}
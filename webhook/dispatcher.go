@@ -0,0 +1,355 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+)
+
+// Events delivered to webhooks
+const (
+	DepositConfirmed = "deposit.confirmed"
+	DepositOrphaned  = "deposit.orphaned"
+)
+
+// Headers sent along with every delivery, the signature is the hex encoded
+// HMAC-SHA256 of the body keyed with the secret of the watch
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	// DefaultMaxAttempts is used when no maximum number of attempts has been configured
+	DefaultMaxAttempts = 10
+	// DefaultTimeout is used when no delivery timeout has been configured
+	DefaultTimeout = 10 * time.Second
+	// FollowUpDepth is how many blocks past its confirmation a deposit is
+	// still watched for reorgs
+	FollowUpDepth = 100
+
+	retryDelay    = 5 * time.Second
+	maxRetryDelay = time.Hour
+	pollInterval  = time.Second
+)
+
+// Payload is the body of a delivery
+type Payload struct {
+	Event                 string                       `json:"event"`
+	WatchID               string                       `json:"watch_id"`
+	Address               string                       `json:"address"`
+	BlockIdentifier       *types.BlockIdentifier       `json:"block_identifier"`
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+	OperationIdentifier   *types.OperationIdentifier   `json:"operation_identifier"`
+	Amount                *types.Amount                `json:"amount"`
+	Confirmations         int64                        `json:"confirmations"`
+}
+
+// Sign returns the signature of a body for the given secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher tracks the deposits to the watched addresses as the indexer adds
+// and removes blocks, and delivers a webhook call once a deposit is confirmed
+// and again if it is orphaned afterwards
+type Dispatcher struct {
+	webhooks    *repository.WebhookProvider
+	httpClient  *http.Client
+	cfg         configuration.Webhooks
+	maxAttempts int
+	trigger     chan struct{}
+	logger      *zap.Logger
+}
+
+// New creates a dispatcher for the watches stored in the repository
+func New(webhooks *repository.WebhookProvider, cfg configuration.Webhooks, logger *zap.Logger) *Dispatcher {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Dispatcher{
+		webhooks:    webhooks,
+		httpClient:  newHTTPClient(cfg, timeout),
+		cfg:         cfg,
+		maxAttempts: maxAttempts,
+		trigger:     make(chan struct{}, 1),
+		logger:      logger,
+	}
+}
+
+// BlockAdded tracks the deposits of the block and confirms the ones that
+// reached the confirmations of their watch
+func (dispatcher *Dispatcher) BlockAdded(block *types.Block) {
+	for _, transaction := range block.Transactions {
+		for _, op := range transaction.Operations {
+			if !isDeposit(op) {
+				continue
+			}
+
+			watches, err := dispatcher.webhooks.WatchesOf(op.Account.Address)
+			if err != nil {
+				dispatcher.logger.Error("unable to get watches", zap.String("address", op.Account.Address), zap.Error(err))
+				continue
+			}
+			for _, watch := range watches {
+				err := dispatcher.webhooks.StoreDeposit(&repository.Deposit{
+					WatchID:               watch.ID,
+					BlockIdentifier:       block.BlockIdentifier,
+					TransactionIdentifier: transaction.TransactionIdentifier,
+					OperationIdentifier:   op.OperationIdentifier,
+					Amount:                op.Amount,
+					Due:                   block.BlockIdentifier.Index + watch.Confirmations - 1,
+				})
+				if err != nil {
+					dispatcher.logger.Error("unable to track deposit",
+						zap.String("hash", transaction.TransactionIdentifier.Hash),
+						zap.String("address", watch.Address),
+						zap.Error(err),
+					)
+				}
+			}
+		}
+	}
+
+	dispatcher.confirm(block.BlockIdentifier.Index)
+}
+
+// BlockRemoved stops tracking the deposits of an orphaned block, the ones
+// already confirmed get a follow-up delivery
+func (dispatcher *Dispatcher) BlockRemoved(block *types.Block) {
+	deposits, err := dispatcher.webhooks.Deposits(block.BlockIdentifier.Index)
+	if err != nil {
+		dispatcher.logger.Error("unable to get deposits of block", zap.Int64("index", block.BlockIdentifier.Index), zap.Error(err))
+		return
+	}
+
+	for _, deposit := range deposits {
+		var delivery *repository.Delivery
+		if deposit.Confirmed {
+			watch, err := dispatcher.webhooks.GetWatch(deposit.WatchID)
+			if err == nil {
+				delivery, err = newDelivery(DepositOrphaned, watch, deposit, 0)
+			}
+			if err != nil && err != repository.ErrNotFound {
+				dispatcher.logger.Error("unable to queue orphaned deposit", zap.String("hash", deposit.TransactionIdentifier.Hash), zap.Error(err))
+			}
+		}
+
+		if err := dispatcher.webhooks.RemoveDeposit(deposit, delivery); err != nil {
+			dispatcher.logger.Error("unable to remove deposit", zap.String("hash", deposit.TransactionIdentifier.Hash), zap.Error(err))
+		}
+	}
+	dispatcher.wake()
+}
+
+// TransactionAdded is a no-op, only mined deposits are delivered
+func (dispatcher *Dispatcher) TransactionAdded(hash string) {}
+
+// TransactionRemoved is a no-op, only mined deposits are delivered
+func (dispatcher *Dispatcher) TransactionRemoved(hash string) {}
+
+// isDeposit returns true for successful operations crediting an account
+func isDeposit(op *types.Operation) bool {
+	if op.Account == nil || op.Amount == nil || op.Status != client.StatusSuccess {
+		return false
+	}
+
+	return op.Amount.Value != "0" && !strings.HasPrefix(op.Amount.Value, "-")
+}
+
+// confirm queues the deposits reaching the confirmations of their watch at the
+// given tip and stops following the ones confirmed long enough ago, only the
+// deposits due at the tip are read
+func (dispatcher *Dispatcher) confirm(tip int64) {
+	deposits, err := dispatcher.webhooks.DueDeposits(tip)
+	if err != nil {
+		dispatcher.logger.Error("unable to get due deposits", zap.Int64("tip", tip), zap.Error(err))
+		return
+	}
+
+	for _, deposit := range deposits {
+		confirmations := tip - deposit.BlockIdentifier.Index + 1
+
+		watch, err := dispatcher.webhooks.GetWatch(deposit.WatchID)
+		switch {
+		case err == repository.ErrNotFound:
+			err = dispatcher.webhooks.RemoveDeposit(deposit, nil)
+		case err != nil:
+		case !deposit.Confirmed:
+			var delivery *repository.Delivery
+			if delivery, err = newDelivery(DepositConfirmed, watch, deposit, confirmations); err == nil {
+				due := deposit.BlockIdentifier.Index + watch.Confirmations + FollowUpDepth
+				err = dispatcher.webhooks.ConfirmDeposit(deposit, due, delivery)
+			}
+		default:
+			err = dispatcher.webhooks.RemoveDeposit(deposit, nil)
+		}
+		if err != nil {
+			dispatcher.logger.Error("unable to update deposit", zap.String("hash", deposit.TransactionIdentifier.Hash), zap.Error(err))
+		}
+	}
+	dispatcher.wake()
+}
+
+func newDelivery(event string, watch *repository.Watch, deposit *repository.Deposit, confirmations int64) (*repository.Delivery, error) {
+	payload, err := json.Marshal(&Payload{
+		Event:                 event,
+		WatchID:               watch.ID,
+		Address:               watch.Address,
+		BlockIdentifier:       deposit.BlockIdentifier,
+		TransactionIdentifier: deposit.TransactionIdentifier,
+		OperationIdentifier:   deposit.OperationIdentifier,
+		Amount:                deposit.Amount,
+		Confirmations:         confirmations,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.Delivery{
+		WatchID: watch.ID,
+		Event:   event,
+		Payload: payload,
+	}, nil
+}
+
+func (dispatcher *Dispatcher) wake() {
+	select {
+	case dispatcher.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Start delivers the queued webhook calls until the context is cancelled,
+// failed deliveries are retried with an exponential backoff
+func (dispatcher *Dispatcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := dispatcher.Deliver(ctx); err != nil && ctx.Err() == nil {
+			dispatcher.logger.Error("delivery failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-dispatcher.trigger:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Deliver attempts every delivery that is due
+func (dispatcher *Dispatcher) Deliver(ctx context.Context) error {
+	deliveries, err := dispatcher.webhooks.DueDeliveries(time.Now())
+	if err != nil {
+		return errors.Wrap(err, "unable to get due deliveries")
+	}
+
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		watch, err := dispatcher.webhooks.GetWatch(delivery.WatchID)
+		if err == repository.ErrNotFound {
+			err = dispatcher.webhooks.RemoveDelivery(delivery.ID)
+		} else if err == nil {
+			err = dispatcher.attempt(ctx, watch, delivery)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to update delivery %s", delivery.ID)
+		}
+	}
+
+	return nil
+}
+
+// attempt calls the webhook once and records the outcome, deliveries to urls
+// no longer allowed by the configuration are dropped
+func (dispatcher *Dispatcher) attempt(ctx context.Context, watch *repository.Watch, delivery *repository.Delivery) error {
+	if err := CheckURL(dispatcher.cfg, watch.URL); err != nil {
+		dispatcher.logger.Error("dropping delivery to a forbidden url",
+			zap.String("delivery", delivery.ID),
+			zap.String("url", watch.URL),
+			zap.Error(err),
+		)
+		return dispatcher.webhooks.RemoveDelivery(delivery.ID)
+	}
+
+	err := dispatcher.post(ctx, watch, delivery)
+	if err == nil {
+		return dispatcher.webhooks.RemoveDelivery(delivery.ID)
+	}
+
+	delivery.Attempts++
+	if delivery.Attempts >= dispatcher.maxAttempts {
+		dispatcher.logger.Error("giving up delivery",
+			zap.String("delivery", delivery.ID),
+			zap.String("url", watch.URL),
+			zap.Int("attempts", delivery.Attempts),
+			zap.Error(err),
+		)
+		return dispatcher.webhooks.RemoveDelivery(delivery.ID)
+	}
+
+	delay := retryDelay << uint(delivery.Attempts-1)
+	if delay > maxRetryDelay || delay <= 0 {
+		delay = maxRetryDelay
+	}
+	delivery.NextAttempt = time.Now().Add(delay)
+	dispatcher.logger.Warn("delivery failed, retrying",
+		zap.String("delivery", delivery.ID),
+		zap.String("url", watch.URL),
+		zap.Duration("delay", delay),
+		zap.Error(err),
+	)
+	return dispatcher.webhooks.UpdateDelivery(delivery)
+}
+
+func (dispatcher *Dispatcher) post(ctx context.Context, watch *repository.Watch, delivery *repository.Delivery) error {
+	request, err := http.NewRequest(http.MethodPost, watch.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, delivery.Event)
+	request.Header.Set(DeliveryHeader, delivery.ID)
+	request.Header.Set(SignatureHeader, Sign(watch.Secret, delivery.Payload))
+
+	response, err := dispatcher.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return errors.Errorf("unexpected status %s", response.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/repository"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/zcoindtest"
+)
+
+const (
+	watched = "TBjJvmhWAT1uLDuA5FU6DvGWpBjt8TBXsu"
+	secret  = "secret"
+)
+
+// receiver records the events of the deliveries with a valid signature and
+// answers with status
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	events   []string
+	rejected int
+}
+
+func (receiver *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	payload := &Payload{}
+	if r.Header.Get(SignatureHeader) != Sign(secret, body) || json.Unmarshal(body, payload) != nil {
+		receiver.rejected++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	receiver.events = append(receiver.events, payload.Event)
+	w.WriteHeader(receiver.status)
+}
+
+// block returns the block at index, the first one pays the watched address
+func block(index int64) *types.Block {
+	transaction := &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: fmt.Sprintf("tx%d", index)},
+	}
+	if index == 1 {
+		transaction.Operations = []*types.Operation{
+			{
+				OperationIdentifier: &types.OperationIdentifier{Index: 0},
+				Status:              client.StatusSuccess,
+				Account:             &types.AccountIdentifier{Address: "TAmVDhmNuC4Qsb3xkwYmzGpBkbtaQUGusx"},
+				Amount:              &types.Amount{Value: "-100", Currency: &types.Currency{Symbol: "XZC", Decimals: 8}},
+			},
+			{
+				OperationIdentifier: &types.OperationIdentifier{Index: 1},
+				Status:              client.StatusSuccess,
+				Account:             &types.AccountIdentifier{Address: watched},
+				Amount:              &types.Amount{Value: "100", Currency: &types.Currency{Symbol: "XZC", Decimals: 8}},
+			},
+		}
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{Index: index, Hash: fmt.Sprintf("block%d", index)},
+		Transactions:    []*types.Transaction{transaction},
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	// blocks lists the heights of the added blocks, negative ones are removed
+	tests := []struct {
+		name          string
+		confirmations int64
+		status        int
+		blocks        []int64
+		want          []string
+		pending       int
+	}{
+		{
+			name:          "confirmed once deep enough",
+			confirmations: 2,
+			status:        http.StatusOK,
+			blocks:        []int64{1, 2},
+			want:          []string{DepositConfirmed},
+		},
+		{
+			name:          "not deep enough",
+			confirmations: 3,
+			status:        http.StatusOK,
+			blocks:        []int64{1, 2},
+		},
+		{
+			name:          "orphaned before its confirmation",
+			confirmations: 2,
+			status:        http.StatusOK,
+			blocks:        []int64{1, -1, 1},
+		},
+		{
+			name:          "orphaned after its confirmation",
+			confirmations: 1,
+			status:        http.StatusOK,
+			blocks:        []int64{1, 2, -2, -1},
+			want:          []string{DepositConfirmed, DepositOrphaned},
+		},
+		{
+			name:          "failed delivery retried later",
+			confirmations: 1,
+			status:        http.StatusInternalServerError,
+			blocks:        []int64{1},
+			want:          []string{DepositConfirmed},
+			pending:       1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := zcoindtest.NewDatabase()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			webhooks := repository.NewWebhookProvider(db.BadgerDB)
+
+			callbacks := &receiver{status: test.status}
+			server := httptest.NewServer(callbacks)
+			defer server.Close()
+			err = webhooks.AddWatch(&repository.Watch{
+				ID:            "watch",
+				Address:       watched,
+				URL:           server.URL,
+				Secret:        secret,
+				Confirmations: test.confirmations,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dispatcher := New(webhooks, configuration.Webhooks{AllowPrivateNetworks: true}, zap.NewNop())
+			ctx := context.Background()
+			for _, index := range test.blocks {
+				if index < 0 {
+					dispatcher.BlockRemoved(block(-index))
+				} else {
+					dispatcher.BlockAdded(block(index))
+				}
+				if err := dispatcher.Deliver(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !reflect.DeepEqual(callbacks.events, test.want) || callbacks.rejected != 0 {
+				t.Errorf("got %v and %d rejected deliveries, want %v", callbacks.events, callbacks.rejected, test.want)
+			}
+			deliveries, err := webhooks.DueDeliveries(time.Now().Add(maxRetryDelay))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deliveries) != test.pending {
+				t.Errorf("got %d pending deliveries, want %d", len(deliveries), test.pending)
+			}
+		})
+	}
+}
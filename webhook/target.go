@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+// privateNetworks are the ranges a webhook may only reach when private
+// networks are allowed, on top of the loopback, link-local and multicast ones
+var privateNetworks = []*net.IPNet{
+	mustParseCIDR("10.0.0.0/8"),
+	mustParseCIDR("172.16.0.0/12"),
+	mustParseCIDR("192.168.0.0/16"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("fc00::/7"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// forbiddenIP returns true for the addresses of the gateway host and of its
+// networks rather than of a public receiver
+func forbiddenIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckURL returns an error when a webhook may not be called at the given url,
+// host names are checked again against the resolved addresses when dialing
+func CheckURL(cfg configuration.Webhooks, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+
+	host := target.Hostname()
+	if len(cfg.AllowedHosts) > 0 {
+		allowed := false
+		for _, allowedHost := range cfg.AllowedHosts {
+			if strings.EqualFold(host, allowedHost) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return errors.Errorf("host %s is not allowed", host)
+		}
+	}
+	if ip := net.ParseIP(host); ip != nil && !cfg.AllowPrivateNetworks && forbiddenIP(ip) {
+		return errors.Errorf("address %s is not public", host)
+	}
+
+	return nil
+}
+
+// newHTTPClient returns a client that only connects to the hosts CheckURL
+// accepts, including after a redirect or a DNS lookup, and never through a proxy
+func newHTTPClient(cfg configuration.Webhooks, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || forbiddenIP(ip) {
+				return errors.Errorf("address %s is not public", host)
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return CheckURL(cfg, request.URL.String())
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   configuration.Webhooks
+		url   string
+		valid bool
+	}{
+		{"public host", configuration.Webhooks{}, "https://hooks.example/deposits", true},
+		{"public address", configuration.Webhooks{}, "http://93.184.216.34:8080/", true},
+		{"other scheme", configuration.Webhooks{}, "ftp://hooks.example/", false},
+		{"relative url", configuration.Webhooks{}, "/deposits", false},
+		{"loopback", configuration.Webhooks{}, "http://127.0.0.1:8080/", false},
+		{"loopback v6", configuration.Webhooks{}, "http://[::1]/", false},
+		{"link-local metadata", configuration.Webhooks{}, "http://169.254.169.254/latest/meta-data", false},
+		{"private network", configuration.Webhooks{}, "http://10.1.2.3/", false},
+		{"unique local v6", configuration.Webhooks{}, "http://[fd00::1]/", false},
+		{"unspecified", configuration.Webhooks{}, "http://0.0.0.0/", false},
+		{"private networks allowed", configuration.Webhooks{AllowPrivateNetworks: true}, "http://10.1.2.3/", true},
+		{"allowed host", configuration.Webhooks{AllowedHosts: []string{"hooks.example"}}, "https://HOOKS.example/", true},
+		{"other host", configuration.Webhooks{AllowedHosts: []string{"hooks.example"}}, "https://evil.example/", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := CheckURL(test.cfg, test.url); (err == nil) != test.valid {
+				t.Errorf("got %v, want valid %t", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestHTTPClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		cfg   configuration.Webhooks
+		valid bool
+	}{
+		{"private networks refused", configuration.Webhooks{}, false},
+		{"private networks allowed", configuration.Webhooks{AllowPrivateNetworks: true}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the host name resolves to the loopback address, only the dialer sees it
+			response, err := newHTTPClient(test.cfg, DefaultTimeout).Get("http://localhost:" + port)
+			if err == nil {
+				response.Body.Close()
+			}
+			if (err == nil) != test.valid {
+				t.Errorf("got %v, want valid %t", err, test.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package zcoindtest
+
+import (
+	"io/ioutil"
+	"os"
+
+	badger "github.com/dgraph-io/badger"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/provider"
+)
+
+// Database is a Badger database in a temporary directory
+type Database struct {
+	*provider.BadgerDB
+	dir string
+}
+
+// NewDatabase opens an empty database, Badger 1.6 cannot run in memory
+func NewDatabase() (*Database, error) {
+	dir, err := ioutil.TempDir("", "zcoin-rosetta")
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := provider.ProvideDatabase(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &Database{
+		BadgerDB: db,
+		dir:      dir,
+	}, nil
+}
+
+// Close closes the database and removes its directory
+func (database *Database) Close() error {
+	defer os.RemoveAll(database.dir)
+	return database.BadgerDB.Close()
+}
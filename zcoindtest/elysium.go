@@ -0,0 +1,232 @@
+package zcoindtest
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+)
+
+var (
+	errNotElysium = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidAddressOrKey,
+		Message: "Not a Elysium Protocol transaction",
+	}
+	errAddressNotFound = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParameter,
+		Message: "Address not found",
+	}
+	errNotSTO = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParameter,
+		Message: "Not a Send To Owners transaction",
+	}
+	errPropertyNotFound = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParameter,
+		Message: "Property identifier does not exist",
+	}
+)
+
+// AddElysiumTransaction attaches an Elysium payload to a transaction already
+// added to the mempool or mined
+func (node *Node) AddElysiumTransaction(tx *client.TxRawResult, payload client.ElysiumTransaction) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	payload.Txid = tx.Txid
+	node.elysium[tx.Txid] = &payload
+}
+
+func (node *Node) elysiumBlockTransactions(height int64) ([]string, *btcjson.RPCError) {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	if height < 0 || height >= int64(len(node.chain)) {
+		return nil, errHeightOutOfRange
+	}
+
+	txids := make([]string, 0)
+	for _, tx := range node.chain[height].block.Tx {
+		if _, ok := node.elysium[tx.Txid]; ok {
+			txids = append(txids, tx.Txid)
+		}
+	}
+
+	return txids, nil
+}
+
+// elysiumTransaction mirrors elysium_gettransaction, the recipients of a send
+// to owners are left to elysium_getsto
+func (node *Node) elysiumTransaction(txid string) (*client.ElysiumTransaction, *btcjson.RPCError) {
+	tx, err := node.elysiumPayload(txid)
+	if err != nil {
+		return nil, err
+	}
+
+	tx.Recipients = nil
+	tx.TotalSTOFee = ""
+	return tx, nil
+}
+
+func (node *Node) elysiumSTO(txid string) (*client.ElysiumTransaction, *btcjson.RPCError) {
+	tx, err := node.elysiumPayload(txid)
+	if err != nil {
+		return nil, err
+	}
+	if tx.TypeInt != client.ELYSIUM_SEND_TO_OWNERS {
+		return nil, errNotSTO
+	}
+
+	return tx, nil
+}
+
+// elysiumPayload returns a copy of the payload of a transaction with the
+// block it was mined in
+func (node *Node) elysiumPayload(txid string) (*client.ElysiumTransaction, *btcjson.RPCError) {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	payload, ok := node.elysium[txid]
+	if !ok {
+		return nil, errNotElysium
+	}
+
+	tx := *payload
+	if entry, ok := node.txs[txid]; ok {
+		block := node.blocks[entry.blockHash]
+		tx.BlockHash = block.block.Hash
+		tx.Block = block.block.Height
+		tx.Confirmations = node.confirmations(block)
+	}
+
+	return &tx, nil
+}
+
+// elysiumProperty mirrors elysium_getproperty for the native tokens and the
+// properties the transactions added to the node refer to
+func (node *Node) elysiumProperty(propertyID int64) (*client.ElysiumProperty, *btcjson.RPCError) {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	divisible := map[int64]bool{
+		client.ELYSIUM_MAIN_TOKEN: true,
+		client.ELYSIUM_TEST_TOKEN: true,
+	}
+	for _, tx := range node.elysium {
+		divisible[tx.PropertyID] = tx.Divisible
+		if tx.PurchasedPropertyID != 0 {
+			divisible[tx.PurchasedPropertyID] = tx.PurchasedPropertyDivisible
+		}
+		for _, subSend := range tx.SubSends {
+			divisible[subSend.PropertyID] = subSend.Divisible
+		}
+	}
+	value, ok := divisible[propertyID]
+	if !ok || propertyID == 0 {
+		return nil, errPropertyNotFound
+	}
+
+	return &client.ElysiumProperty{
+		PropertyID: propertyID,
+		Name:       fmt.Sprintf("Property %d", propertyID),
+		Divisible:  value,
+	}, nil
+}
+
+// elysiumUnits parses a decimal token amount into units
+func elysiumUnits(amount string, divisible bool) *big.Int {
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return new(big.Int)
+	}
+	if divisible {
+		value.Mul(value, new(big.Rat).SetInt64(1e8))
+	}
+
+	return new(big.Int).Quo(value.Num(), value.Denom())
+}
+
+// elysiumBalances mirrors elysium_getallbalancesforaddress, only valid
+// transactions of the main chain move tokens
+func (node *Node) elysiumBalances(address string) ([]client.ElysiumBalance, *btcjson.RPCError) {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	balances := make(map[int64]*big.Int)
+	divisible := make(map[int64]bool)
+	credit := func(owner string, property int64, propertyDivisible bool, amount string, sign int64) {
+		if owner != address {
+			return
+		}
+		if balances[property] == nil {
+			balances[property] = new(big.Int)
+		}
+		units := elysiumUnits(amount, propertyDivisible)
+		balances[property].Add(balances[property], units.Mul(units, big.NewInt(sign)))
+		divisible[property] = propertyDivisible
+	}
+
+	for _, entry := range node.chain {
+		for _, raw := range entry.block.Tx {
+			tx, ok := node.elysium[raw.Txid]
+			if !ok || !tx.Valid {
+				continue
+			}
+			switch tx.TypeInt {
+			case client.ELYSIUM_SIMPLE_SEND:
+				credit(tx.SendingAddress, tx.PropertyID, tx.Divisible, tx.Amount, -1)
+				credit(tx.ReferenceAddress, tx.PropertyID, tx.Divisible, tx.Amount, 1)
+				if tx.PurchasedPropertyID != 0 {
+					credit(tx.SendingAddress, tx.PurchasedPropertyID, tx.PurchasedPropertyDivisible, tx.PurchasedTokens, 1)
+					credit(tx.ReferenceAddress, tx.PurchasedPropertyID, tx.PurchasedPropertyDivisible, tx.IssuerTokens, 1)
+				}
+			case client.ELYSIUM_SEND_TO_OWNERS:
+				for _, recipient := range tx.Recipients {
+					credit(tx.SendingAddress, tx.PropertyID, tx.Divisible, recipient.Amount, -1)
+					credit(recipient.Address, tx.PropertyID, tx.Divisible, recipient.Amount, 1)
+				}
+				credit(tx.SendingAddress, client.ELYSIUM_MAIN_TOKEN, true, tx.TotalSTOFee, -1)
+			case client.ELYSIUM_SEND_ALL:
+				for _, subSend := range tx.SubSends {
+					credit(tx.SendingAddress, subSend.PropertyID, subSend.Divisible, subSend.Amount, -1)
+					credit(tx.ReferenceAddress, subSend.PropertyID, subSend.Divisible, subSend.Amount, 1)
+				}
+			case client.ELYSIUM_CREATE_FIXED:
+				credit(tx.SendingAddress, tx.PropertyID, tx.Divisible, tx.Amount, 1)
+			case client.ELYSIUM_GRANT_TOKENS:
+				receiver := tx.ReferenceAddress
+				if receiver == "" {
+					receiver = tx.SendingAddress
+				}
+				credit(receiver, tx.PropertyID, tx.Divisible, tx.Amount, 1)
+			case client.ELYSIUM_REVOKE_TOKENS:
+				credit(tx.SendingAddress, tx.PropertyID, tx.Divisible, tx.Amount, -1)
+			}
+		}
+	}
+	if len(balances) == 0 {
+		return nil, errAddressNotFound
+	}
+
+	result := make([]client.ElysiumBalance, 0, len(balances))
+	for property, units := range balances {
+		balance := units.String()
+		reserved := "0"
+		if divisible[property] {
+			balance = new(big.Rat).SetFrac(units, big.NewInt(1e8)).FloatString(8)
+			reserved = "0.00000000"
+		}
+		result = append(result, client.ElysiumBalance{
+			PropertyID: property,
+			Name:       fmt.Sprintf("Property %d", property),
+			Balance:    balance,
+			Reserved:   reserved,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PropertyID < result[j].PropertyID
+	})
+
+	return result, nil
+}
@@ -0,0 +1,638 @@
+// Package zcoindtest provides an in-memory stand-in for zcoind that serves the
+// JSON-RPC calls used by the gateway from a scripted chain.
+package zcoindtest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+)
+
+const (
+	// DefaultMinerAddress receives the coinbase of every mined block
+	DefaultMinerAddress = "TRuQiGL8F6a5e3Tb5QVp1HFn4tr2GfLDaL"
+	// DefaultReward is the coinbase value of every mined block in XZC
+	DefaultReward = 50.0
+	// GenesisTime is the timestamp of the scripted genesis block
+	GenesisTime = 1414776286
+	// BlockInterval is the time between two scripted blocks in seconds
+	BlockInterval = 300
+)
+
+type blockEntry struct {
+	block *client.GetBlockVerboseTxResult
+}
+
+type txEntry struct {
+	tx        *client.TxRawResult
+	blockHash string
+}
+
+// Node is a scripted zcoind chain. The zero value is not usable, use NewNode.
+type Node struct {
+	mu           sync.RWMutex
+	minerAddress string
+	reward       float64
+	chain        []*blockEntry
+	blocks       map[string]*blockEntry
+	txs          map[string]*txEntry
+	elysium      map[string]*client.ElysiumTransaction
+	znodes       []*znodeEntry
+	mempool      []*client.TxRawResult
+	sequence     int64
+	publisher    *Publisher
+}
+
+// NewNode creates a stand-in node with a mined genesis block
+func NewNode() *Node {
+	node := &Node{
+		minerAddress: DefaultMinerAddress,
+		reward:       DefaultReward,
+		blocks:       make(map[string]*blockEntry),
+		txs:          make(map[string]*txEntry),
+		elysium:      make(map[string]*client.ElysiumTransaction),
+	}
+	node.Mine()
+
+	return node
+}
+
+// SetMinerAddress sets the address receiving the coinbase of the blocks mined
+// from now on
+func (node *Node) SetMinerAddress(address string) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	node.minerAddress = address
+}
+
+// SetReward sets the coinbase value of the blocks mined from now on
+func (node *Node) SetReward(reward float64) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	node.reward = reward
+}
+
+// SetPublisher makes the node publish hashblock and rawtx notifications
+func (node *Node) SetPublisher(publisher *Publisher) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	node.publisher = publisher
+}
+
+// Output returns a pay-to-pubkey-hash output paying amount XZC to address
+func Output(address string, amount float64) btcjson.Vout {
+	return btcjson.Vout{
+		Value: amount,
+		ScriptPubKey: btcjson.ScriptPubKeyResult{
+			Type:      "pubkeyhash",
+			ReqSigs:   1,
+			Addresses: []string{address},
+		},
+	}
+}
+
+// Input returns an input spending output n of tx
+func Input(tx *client.TxRawResult, n uint32) client.Vin {
+	return client.Vin{
+		Txid:      tx.Txid,
+		Vout:      n,
+		ScriptSig: &btcjson.ScriptSig{},
+		Sequence:  wire.MaxTxInSequenceNum,
+	}
+}
+
+// Transaction returns a transaction spending inputs into outputs, its hash is
+// assigned once it is mined or added to the mempool
+func Transaction(inputs []client.Vin, outputs ...btcjson.Vout) *client.TxRawResult {
+	return &client.TxRawResult{
+		Version: 1,
+		Vin:     inputs,
+		Vout:    outputs,
+	}
+}
+
+// NullData returns an OP_RETURN output carrying data
+func NullData(data []byte) btcjson.Vout {
+	script, _ := txscript.NullDataScript(data)
+	return btcjson.Vout{
+		ScriptPubKey: btcjson.ScriptPubKeyResult{
+			Hex:  hex.EncodeToString(script),
+			Type: client.NULL_DATA,
+		},
+	}
+}
+
+// Multisig returns a bare multisig output locking amount XZC to addresses
+func Multisig(amount float64, addresses ...string) btcjson.Vout {
+	return btcjson.Vout{
+		Value: amount,
+		ScriptPubKey: btcjson.ScriptPubKeyResult{
+			Hex:       fmt.Sprintf("%x%xae", len(addresses), strings.Join(addresses, "")),
+			Type:      client.MULTISIG,
+			ReqSigs:   int32(len(addresses)),
+			Addresses: addresses,
+		},
+	}
+}
+
+// SigmaMint returns an output minting amount XZC into the sigma pool
+func SigmaMint(amount float64) btcjson.Vout {
+	return btcjson.Vout{
+		Value: amount,
+		ScriptPubKey: btcjson.ScriptPubKeyResult{
+			Hex:  fmt.Sprintf("%x", client.OP_SIGMAMINT),
+			Type: client.SIGMA_MINT,
+		},
+	}
+}
+
+// SigmaSpend returns an input redeeming a sigma mint of the given denomination
+func SigmaSpend(denomination float64) client.Vin {
+	group := int64(1)
+	return client.Vin{
+		ScriptSig:      &btcjson.ScriptSig{Hex: fmt.Sprintf("%x", client.OP_SIGMASPEND)},
+		Sequence:       wire.MaxTxInSequenceNum,
+		AnonymityGroup: &group,
+		Value:          denomination,
+	}
+}
+
+// LelantusMint returns an output minting amount XZC into the lelantus pool
+func LelantusMint(amount float64) btcjson.Vout {
+	return btcjson.Vout{
+		Value: amount,
+		ScriptPubKey: btcjson.ScriptPubKeyResult{
+			Hex:  fmt.Sprintf("%x", client.OP_LELANTUSMINT),
+			Type: client.LELANTUS_MINT,
+		},
+	}
+}
+
+// LelantusJMint returns a joinsplit output, its value stays hidden in the pool
+func LelantusJMint() btcjson.Vout {
+	return btcjson.Vout{
+		ScriptPubKey: btcjson.ScriptPubKeyResult{
+			Hex:  fmt.Sprintf("%x", client.OP_LELANTUSJMINT),
+			Type: "lelantusjmint",
+		},
+	}
+}
+
+// JoinSplit returns a lelantus joinsplit input paying fee XZC
+func JoinSplit(fee float64) client.Vin {
+	return client.Vin{
+		ScriptSig: &btcjson.ScriptSig{Hex: fmt.Sprintf("%x", client.OP_LELANTUSJOINSPLIT)},
+		Sequence:  wire.MaxTxInSequenceNum,
+		NFees:     &fee,
+	}
+}
+
+// Coinbase returns a coinbase transaction paying amount XZC to address
+func Coinbase(address string, amount float64) *client.TxRawResult {
+	return Transaction([]client.Vin{{
+		Coinbase: "00",
+		Sequence: wire.MaxTxInSequenceNum,
+	}}, Output(address, amount))
+}
+
+// medianHeight returns the height whose time is the median of the last 11
+// blocks, scripted block times always increase
+func medianHeight(height int64) int64 {
+	first := height - 10
+	if first < 0 {
+		first = 0
+	}
+
+	return (first + height) / 2
+}
+
+// cbTxPayload serializes a version 2 CbTx committing to empty Znode and
+// quorum lists
+func cbTxPayload(height int64) string {
+	payload := make([]byte, 6+2*chainhash.HashSize)
+	binary.LittleEndian.PutUint16(payload, 2)
+	binary.LittleEndian.PutUint32(payload[2:], uint32(height))
+
+	return hex.EncodeToString(payload)
+}
+
+func isCoinbase(tx *client.TxRawResult) bool {
+	return len(tx.Vin) == 1 && tx.Vin[0].IsCoinBase()
+}
+
+func (node *Node) nextHash(kind string, data ...string) string {
+	node.sequence++
+	payload := fmt.Sprintf("%s:%d:%s", kind, node.sequence, strings.Join(data, ":"))
+	return chainhash.DoubleHashH([]byte(payload)).String()
+}
+
+// prepare assigns the hash and output positions of a scripted transaction
+func (node *Node) prepare(tx *client.TxRawResult) {
+	if tx.Txid == "" {
+		tx.Txid = node.nextHash("tx")
+	}
+	if tx.Hash == "" {
+		tx.Hash = tx.Txid
+	}
+	for n := range tx.Vout {
+		tx.Vout[n].N = uint32(n)
+	}
+}
+
+func (node *Node) tip() *blockEntry {
+	if len(node.chain) == 0 {
+		return nil
+	}
+
+	return node.chain[len(node.chain)-1]
+}
+
+// Mine appends a block with a coinbase, the given transactions and the
+// current mempool to the chain and returns it
+func (node *Node) Mine(txs ...*client.TxRawResult) *client.GetBlockVerboseTxResult {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	height := int64(len(node.chain))
+	previousHash := ""
+	if tip := node.tip(); tip != nil {
+		previousHash = tip.block.Hash
+	}
+
+	if len(txs) == 0 || !isCoinbase(txs[0]) {
+		coinbase := Coinbase(node.minerAddress, node.reward)
+		coinbase.Version = 3
+		coinbase.Type = client.COINBASE
+		coinbase.ExtraPayload = cbTxPayload(height)
+		coinbase.ExtraPayloadSize = len(coinbase.ExtraPayload) / 2
+		txs = append([]*client.TxRawResult{coinbase}, txs...)
+	}
+	txs = append(txs, node.mempool...)
+	node.mempool = nil
+
+	txids := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		node.prepare(tx)
+		txids = append(txids, tx.Txid)
+	}
+
+	hash := node.nextHash("block", previousHash, strings.Join(txids, ","))
+	// the genesis block is the regtest one so the gateway accepts the chain
+	if height == 0 {
+		hash, _ = client.GenesisHash(client.REGTEST)
+	}
+	block := &client.GetBlockVerboseTxResult{
+		Hash:         hash,
+		Height:       height,
+		Version:      4,
+		VersionHex:   "00000004",
+		MerkleRoot:   chainhash.DoubleHashH([]byte(strings.Join(txids, ""))).String(),
+		Time:         GenesisTime + height*BlockInterval,
+		Bits:         "1e0ffff0",
+		Nonce:        uint32(height),
+		Difficulty:   1,
+		PreviousHash: previousHash,
+		MedianTime:   GenesisTime + medianHeight(height)*BlockInterval,
+	}
+	// every block after genesis is mined with MTP
+	if height > 0 {
+		block.MTPVersion = "0x1000"
+		block.MTPHashValue = chainhash.DoubleHashH([]byte(block.Hash)).String()
+		block.MTPReserved0 = strings.Repeat("0", 64)
+		block.MTPReserved1 = strings.Repeat("0", 64)
+	}
+	for _, tx := range txs {
+		tx.BlockHash = block.Hash
+		tx.Time = block.Time
+		tx.Blocktime = block.Time
+		block.Tx = append(block.Tx, *tx)
+		node.txs[tx.Txid] = &txEntry{tx: tx, blockHash: block.Hash}
+	}
+
+	entry := &blockEntry{block: block}
+	node.chain = append(node.chain, entry)
+	node.blocks[block.Hash] = entry
+
+	if node.publisher != nil {
+		// like zcoind, rawtx is published again for the transactions of the block
+		for _, tx := range txs {
+			if raw, err := hex.DecodeString(tx.Hex); err == nil && len(raw) > 0 {
+				node.publisher.PublishTransaction(raw)
+			}
+		}
+		node.publisher.PublishBlock(block.Hash)
+	}
+
+	return block
+}
+
+// Reorg disconnects the latest depth blocks, returning their transactions to
+// the mempool. Blocks mined afterwards build the competing branch while the
+// orphaned blocks remain retrievable by hash.
+func (node *Node) Reorg(depth int) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if depth >= len(node.chain) {
+		depth = len(node.chain) - 1
+	}
+
+	for i := 0; i < depth; i++ {
+		tip := node.tip()
+		node.chain = node.chain[:len(node.chain)-1]
+
+		var returned []*client.TxRawResult
+		for _, tx := range tip.block.Tx {
+			delete(node.txs, tx.Txid)
+			if isCoinbase(&tx) {
+				continue
+			}
+			tx := tx
+			tx.BlockHash, tx.Time, tx.Blocktime = "", 0, 0
+			returned = append(returned, &tx)
+		}
+		node.mempool = append(returned, node.mempool...)
+	}
+}
+
+// AddToMempool adds scripted transactions to the mempool
+func (node *Node) AddToMempool(txs ...*client.TxRawResult) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	for _, tx := range txs {
+		node.prepare(tx)
+		node.mempool = append(node.mempool, tx)
+	}
+}
+
+// Height returns the height of the current tip
+func (node *Node) Height() int64 {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	return int64(len(node.chain) - 1)
+}
+
+// BlockAt returns the main chain block at the given height
+func (node *Node) BlockAt(height int64) *client.GetBlockVerboseTxResult {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	if height < 0 || height >= int64(len(node.chain)) {
+		return nil
+	}
+
+	return node.chain[height].block
+}
+
+func (node *Node) isMainChain(entry *blockEntry) bool {
+	height := entry.block.Height
+	return height < int64(len(node.chain)) && node.chain[height] == entry
+}
+
+// confirmations mirrors zcoind, orphaned blocks have -1 confirmations
+func (node *Node) confirmations(entry *blockEntry) int64 {
+	if !node.isMainChain(entry) {
+		return -1
+	}
+
+	return int64(len(node.chain)) - entry.block.Height
+}
+
+func (node *Node) nextHashOf(entry *blockEntry) string {
+	if !node.isMainChain(entry) || entry.block.Height+1 >= int64(len(node.chain)) {
+		return ""
+	}
+
+	return node.chain[entry.block.Height+1].block.Hash
+}
+
+// verboseBlock and verboseTxBlock must be called with the lock held
+func (node *Node) verboseBlock(entry *blockEntry) *btcjson.GetBlockVerboseResult {
+	block := entry.block
+	txids := make([]string, 0, len(block.Tx))
+	for _, tx := range block.Tx {
+		txids = append(txids, tx.Txid)
+	}
+
+	return &btcjson.GetBlockVerboseResult{
+		Hash:          block.Hash,
+		Confirmations: node.confirmations(entry),
+		Size:          block.Size,
+		Weight:        block.Weight,
+		Height:        block.Height,
+		Version:       block.Version,
+		VersionHex:    block.VersionHex,
+		MerkleRoot:    block.MerkleRoot,
+		Tx:            txids,
+		Time:          block.Time,
+		Nonce:         block.Nonce,
+		Bits:          block.Bits,
+		Difficulty:    block.Difficulty,
+		PreviousHash:  block.PreviousHash,
+		NextHash:      node.nextHashOf(entry),
+	}
+}
+
+func (node *Node) verboseTxBlock(entry *blockEntry) *client.GetBlockVerboseTxResult {
+	block := *entry.block
+	block.Confirmations = node.confirmations(entry)
+	block.NextHash = node.nextHashOf(entry)
+
+	return &block
+}
+
+// decodeTransaction builds the verbose representation of a serialized transaction
+func decodeTransaction(raw []byte) (*client.TxRawResult, error) {
+	msgTx := &wire.MsgTx{}
+	if err := msgTx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return nil, err
+	}
+
+	tx := &client.TxRawResult{
+		Hex:      hex.EncodeToString(raw),
+		Txid:     msgTx.TxHash().String(),
+		Hash:     msgTx.WitnessHash().String(),
+		Size:     int32(len(raw)),
+		Version:  msgTx.Version,
+		LockTime: msgTx.LockTime,
+	}
+	for _, in := range msgTx.TxIn {
+		tx.Vin = append(tx.Vin, client.Vin{
+			Txid:      in.PreviousOutPoint.Hash.String(),
+			Vout:      in.PreviousOutPoint.Index,
+			ScriptSig: &btcjson.ScriptSig{Hex: hex.EncodeToString(in.SignatureScript)},
+			Sequence:  in.Sequence,
+		})
+	}
+	for n, out := range msgTx.TxOut {
+		tx.Vout = append(tx.Vout, btcjson.Vout{
+			Value: float64(out.Value) / 1e8,
+			N:     uint32(n),
+			ScriptPubKey: btcjson.ScriptPubKeyResult{
+				Hex:  hex.EncodeToString(out.PkScript),
+				Type: txscript.GetScriptClass(out.PkScript).String(),
+			},
+		})
+	}
+
+	return tx, nil
+}
+
+// sendRawTransaction accepts a serialized transaction into the mempool
+func (node *Node) sendRawTransaction(raw []byte) (string, *btcjson.RPCError) {
+	tx, err := decodeTransaction(raw)
+	if err != nil {
+		return "", errDecodeFailed
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if _, ok := node.txs[tx.Txid]; ok {
+		return "", errAlreadyInChain
+	}
+	for _, pending := range node.mempool {
+		if pending.Txid == tx.Txid {
+			return "", errAlreadyInMempool
+		}
+	}
+	node.mempool = append(node.mempool, tx)
+
+	if node.publisher != nil {
+		node.publisher.PublishTransaction(raw)
+	}
+
+	return tx.Txid, nil
+}
+
+func (node *Node) getRawTransaction(txid string) (*client.TxRawResult, *btcjson.RPCError) {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	if entry, ok := node.txs[txid]; ok {
+		tx := *entry.tx
+		tx.Confirmations = node.confirmations(node.blocks[entry.blockHash])
+		return &tx, nil
+	}
+	for _, tx := range node.mempool {
+		if tx.Txid == txid {
+			tx := *tx
+			return &tx, nil
+		}
+	}
+
+	return nil, errNoTransaction
+}
+
+func (node *Node) blockChainInfo() *btcjson.GetBlockChainInfoResult {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	tip := node.tip().block
+	return &btcjson.GetBlockChainInfoResult{
+		Chain:                "regtest",
+		Blocks:               int32(tip.Height),
+		Headers:              int32(tip.Height),
+		BestBlockHash:        tip.Hash,
+		Difficulty:           tip.Difficulty,
+		MedianTime:           tip.Time,
+		VerificationProgress: 1,
+		ChainWork:            fmt.Sprintf("%064x", tip.Height+1),
+	}
+}
+
+// addressBalance mirrors getaddressbalance, only confirmed main chain
+// transactions are taken into account
+func (node *Node) addressBalance(address string) map[string]int64 {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	var balance, received int64
+	for _, entry := range node.chain {
+		for _, tx := range entry.block.Tx {
+			for _, in := range tx.Vin {
+				prev, ok := node.txs[in.Txid]
+				if in.IsCoinBase() || !ok || int(in.Vout) >= len(prev.tx.Vout) {
+					continue
+				}
+				if out := prev.tx.Vout[in.Vout]; ownedBy(out, address) {
+					amount, _ := btcutil.NewAmount(out.Value)
+					balance -= int64(amount)
+				}
+			}
+			for _, out := range tx.Vout {
+				if ownedBy(out, address) {
+					amount, _ := btcutil.NewAmount(out.Value)
+					balance += int64(amount)
+					received += int64(amount)
+				}
+			}
+		}
+	}
+
+	return map[string]int64{
+		"balance":  balance,
+		"received": received,
+	}
+}
+
+func ownedBy(out btcjson.Vout, address string) bool {
+	return len(out.ScriptPubKey.Addresses) == 1 && out.ScriptPubKey.Addresses[0] == address
+}
+
+func (node *Node) mempoolHashes() []string {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	hashes := make([]string, 0, len(node.mempool))
+	for _, tx := range node.mempool {
+		hashes = append(hashes, tx.Txid)
+	}
+
+	return hashes
+}
+
+func (node *Node) getBlock(hash string, verbosity int) (interface{}, *btcjson.RPCError) {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	entry, ok := node.blocks[hash]
+	if !ok {
+		return nil, errBlockNotFound
+	}
+
+	switch verbosity {
+	case 1:
+		return node.verboseBlock(entry), nil
+	case 2:
+		return node.verboseTxBlock(entry), nil
+	default:
+		return nil, errVerbosityUnsupported
+	}
+}
+
+func (node *Node) blockHash(height int64) (string, *btcjson.RPCError) {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	if height < 0 || height >= int64(len(node.chain)) {
+		return "", errHeightOutOfRange
+	}
+
+	return node.chain[height].block.Hash, nil
+}
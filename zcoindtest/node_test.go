@@ -0,0 +1,338 @@
+package zcoindtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/go-zeromq/zmq4"
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+)
+
+// params encodes the parameters of an RPC call
+func params(t *testing.T, values ...interface{}) []json.RawMessage {
+	encoded := make([]json.RawMessage, 0, len(values))
+	for _, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded = append(encoded, raw)
+	}
+
+	return encoded
+}
+
+// serialize builds a transaction spending the given output of a made up
+// funding transaction
+func serialize(t *testing.T, index uint32) (string, []byte) {
+	funding := chainhash.DoubleHashH([]byte("funding"))
+	msgTx := wire.NewMsgTx(1)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&funding, index), nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e8, []byte{0x51}))
+
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return msgTx.TxHash().String(), buf.Bytes()
+}
+
+func TestGetBlock(t *testing.T) {
+	node := NewNode()
+	node.AddToMempool(Transaction([]client.Vin{Input(&node.BlockAt(0).Tx[0], 0)}, Output(DefaultMinerAddress, 49)))
+	block := node.Mine()
+
+	tests := []struct {
+		name   string
+		params []json.RawMessage
+		// want is the number of transactions listed, txids for verbosity 1
+		// and verbose transactions for verbosity 2
+		want    int
+		verbose bool
+		err     *btcjson.RPCError
+	}{
+		{name: "default verbosity", params: params(t, block.Hash), want: 2},
+		{name: "verbosity 0", params: params(t, block.Hash, 0), err: errVerbosityUnsupported},
+		{name: "verbose false", params: params(t, block.Hash, false), err: errVerbosityUnsupported},
+		{name: "verbosity 1", params: params(t, block.Hash, 1), want: 2},
+		{name: "verbose true", params: params(t, block.Hash, true), want: 2},
+		{name: "verbosity 2", params: params(t, block.Hash, 2), want: 2, verbose: true},
+		{name: "unknown hash", params: params(t, chainhash.Hash{}.String(), 1), err: errBlockNotFound},
+		{name: "missing hash", err: errInvalidParams},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := node.Call("getblock", test.params)
+			if err != test.err {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if err != nil {
+				return
+			}
+
+			switch result := result.(type) {
+			case *btcjson.GetBlockVerboseResult:
+				if test.verbose || len(result.Tx) != test.want || result.Hash != block.Hash || result.Confirmations != 1 {
+					t.Errorf("got %+v, want %d txids of block %s", result, test.want, block.Hash)
+				}
+			case *client.GetBlockVerboseTxResult:
+				if !test.verbose || len(result.Tx) != test.want || result.Tx[1].Txid != block.Tx[1].Txid {
+					t.Errorf("got %+v, want %d transactions of block %s", result, test.want, block.Hash)
+				}
+			default:
+				t.Errorf("got %T", result)
+			}
+		})
+	}
+}
+
+func TestSendRawTransaction(t *testing.T) {
+	node := NewNode()
+	mined, minedRaw := serialize(t, 0)
+	if _, err := node.Call("sendrawtransaction", params(t, hex.EncodeToString(minedRaw))); err != nil {
+		t.Fatal(err)
+	}
+	node.Mine()
+	pending, pendingRaw := serialize(t, 1)
+	_, freshRaw := serialize(t, 2)
+
+	tests := []struct {
+		name string
+		raw  string
+		err  *btcjson.RPCError
+	}{
+		{name: "new transaction", raw: hex.EncodeToString(freshRaw)},
+		{name: "in the mempool", raw: hex.EncodeToString(pendingRaw), err: errAlreadyInMempool},
+		{name: "in the chain", raw: hex.EncodeToString(minedRaw), err: errAlreadyInChain},
+		{name: "not hex", raw: "zz", err: errDecodeFailed},
+		{name: "truncated", raw: hex.EncodeToString(freshRaw[:10]), err: errDecodeFailed},
+	}
+
+	if _, err := node.Call("sendrawtransaction", params(t, hex.EncodeToString(pendingRaw))); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			txid, err := node.Call("sendrawtransaction", params(t, test.raw))
+			if err != test.err {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if err == nil && txid == "" {
+				t.Error("no txid returned")
+			}
+		})
+	}
+
+	if tx, err := node.getRawTransaction(mined); err != nil || tx.Confirmations != 1 {
+		t.Errorf("got %+v and %v for the mined transaction", tx, err)
+	}
+	if tx, err := node.getRawTransaction(pending); err != nil || tx.BlockHash != "" {
+		t.Errorf("got %+v and %v for the pending transaction", tx, err)
+	}
+}
+
+func TestMineEvictsMempool(t *testing.T) {
+	tests := []struct {
+		name    string
+		pending int
+		// scripted transactions passed to Mine on top of the mempool
+		scripted int
+	}{
+		{name: "empty mempool"},
+		{name: "mempool", pending: 2},
+		{name: "mempool and scripted transactions", pending: 1, scripted: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := NewNode()
+			for i := 0; i < test.pending; i++ {
+				_, raw := serialize(t, uint32(i))
+				if _, err := node.sendRawTransaction(raw); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var scripted []*client.TxRawResult
+			for i := 0; i < test.scripted; i++ {
+				scripted = append(scripted, Transaction(nil, Output(DefaultMinerAddress, float64(i+1))))
+			}
+
+			pending := node.mempoolHashes()
+			block := node.Mine(scripted...)
+			if left := node.mempoolHashes(); len(left) != 0 {
+				t.Errorf("%d transactions left in the mempool", len(left))
+			}
+			if len(block.Tx) != 1+test.scripted+test.pending {
+				t.Fatalf("got %d transactions, want the coinbase, %d scripted and %d pending", len(block.Tx), test.scripted, test.pending)
+			}
+			for _, txid := range pending {
+				if tx, err := node.getRawTransaction(txid); err != nil || tx.BlockHash != block.Hash {
+					t.Errorf("got %+v and %v for %s, want it mined in %s", tx, err, txid, block.Hash)
+				}
+			}
+		})
+	}
+}
+
+func TestReorg(t *testing.T) {
+	tests := []struct {
+		name  string
+		depth int
+		// height is the height left after the reorg
+		height int64
+	}{
+		{name: "tip", depth: 1, height: 2},
+		{name: "two blocks", depth: 2, height: 1},
+		{name: "past genesis", depth: 5, height: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := NewNode()
+			node.Mine()
+			_, raw := serialize(t, 0)
+			txid, err := node.sendRawTransaction(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			node.Mine()
+			orphaned := node.Mine()
+
+			node.Reorg(test.depth)
+			if height := node.Height(); height != test.height {
+				t.Fatalf("got height %d, want %d", height, test.height)
+			}
+
+			// orphaned blocks stay retrievable with -1 confirmations
+			result, rpcErr := node.Call("getblock", params(t, orphaned.Hash, 1))
+			if block, ok := result.(*btcjson.GetBlockVerboseResult); rpcErr != nil || !ok || block.Confirmations != -1 {
+				t.Errorf("got %+v and %v for the orphaned tip", result, rpcErr)
+			}
+			if _, rpcErr := node.Call("getblockhash", params(t, orphaned.Height)); rpcErr != errHeightOutOfRange {
+				t.Errorf("got %v for the orphaned height, want %v", rpcErr, errHeightOutOfRange)
+			}
+			if _, rpcErr := node.getRawTransaction(orphaned.Tx[0].Txid); rpcErr != errNoTransaction {
+				t.Errorf("got %v for the orphaned coinbase, want %v", rpcErr, errNoTransaction)
+			}
+
+			// the transaction returns to the mempool once its block is orphaned
+			returned := test.depth >= 2
+			if pending := node.mempoolHashes(); (len(pending) == 1 && pending[0] == txid) != returned {
+				t.Errorf("got mempool %v, transaction returned: %t", pending, returned)
+			}
+
+			replacement := node.Mine()
+			if replacement.Height != test.height+1 || replacement.Hash == orphaned.Hash {
+				t.Errorf("got block %d %s on top of height %d", replacement.Height, replacement.Hash, test.height)
+			}
+			if tx, rpcErr := node.getRawTransaction(txid); rpcErr != nil || tx.BlockHash == "" {
+				t.Errorf("got %+v and %v, want the transaction mined again", tx, rpcErr)
+			}
+		})
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	publisher, err := NewPublisher(ctx, "tcp://127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer publisher.Close()
+	subscriber := zmq4.NewSub(ctx)
+	defer subscriber.Close()
+	if err := subscriber.Dial(publisher.Endpoint()); err != nil {
+		t.Fatal(err)
+	}
+	for _, topic := range []string{"hashblock", "rawtx"} {
+		if err := subscriber.SetOption(zmq4.OptionSubscribe, topic); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	node := NewNode()
+	node.SetPublisher(publisher)
+	received := make(chan zmq4.Msg)
+	go func() {
+		for {
+			msg, err := subscriber.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case received <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// messages published before the subscription is in place are dropped,
+	// blocks are mined until one is received and the others are drained
+	sequence := make(map[string]uint32)
+	for len(sequence) == 0 {
+		node.Mine()
+		for drained := false; !drained; {
+			select {
+			case msg := <-received:
+				sequence[string(msg.Frames[0])] = binary.LittleEndian.Uint32(msg.Frames[2]) + 1
+			case <-time.After(100 * time.Millisecond):
+				drained = true
+			}
+		}
+	}
+
+	_, raw := serialize(t, 0)
+	if _, rpcErr := node.sendRawTransaction(raw); rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+	block := node.Mine()
+	blockHash, err := hex.DecodeString(block.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		topic string
+		body  []byte
+	}{
+		{name: "accepted transaction", topic: "rawtx", body: raw},
+		// scripted transactions like the coinbase have no serialization
+		{name: "mined transaction", topic: "rawtx", body: raw},
+		{name: "block", topic: "hashblock", body: blockHash},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var msg zmq4.Msg
+			select {
+			case msg = <-received:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the notification")
+			}
+			if len(msg.Frames) != 3 || string(msg.Frames[0]) != test.topic || !bytes.Equal(msg.Frames[1], test.body) {
+				t.Fatalf("got %q, want %s %x", msg.Frames, test.topic, test.body)
+			}
+
+			// sequence numbers count per topic
+			got := binary.LittleEndian.Uint32(msg.Frames[2])
+			if got != sequence[test.topic] {
+				t.Errorf("got sequence %d, want %d", got, sequence[test.topic])
+			}
+			sequence[test.topic] = got + 1
+		})
+	}
+}
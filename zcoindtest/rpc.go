@@ -0,0 +1,232 @@
+package zcoindtest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// Errors mirror the codes and messages returned by zcoind
+var (
+	errBlockNotFound = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidAddressOrKey,
+		Message: "Block not found",
+	}
+	errHeightOutOfRange = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParameter,
+		Message: "Block height out of range",
+	}
+	errNoTransaction = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidAddressOrKey,
+		Message: "No such mempool or blockchain transaction. Use gettransaction for wallet transactions.",
+	}
+	errAlreadyInChain = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCTxAlreadyInChain,
+		Message: "transaction already in block chain",
+	}
+	errAlreadyInMempool = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCTxRejected,
+		Message: "txn-already-in-mempool",
+	}
+	errVerbosityUnsupported = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParameter,
+		Message: "only verbosity 1 and 2 are supported by the stand-in node",
+	}
+	errMethodNotFound = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCMethodNotFound.Code,
+		Message: "Method not found",
+	}
+	errInvalidParams = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParams.Code,
+		Message: "Invalid parameters",
+	}
+	errDecodeFailed = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCDeserialization,
+		Message: "TX decode failed",
+	}
+)
+
+// NodeVersion is reported by getinfo
+const NodeVersion = 140003
+
+type request struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	ID     interface{}       `json:"id"`
+}
+
+type response struct {
+	Result interface{}       `json:"result"`
+	Error  *btcjson.RPCError `json:"error"`
+	ID     interface{}       `json:"id"`
+}
+
+// ServeHTTP answers a JSON-RPC request the way zcoind does in HTTP POST mode
+func (node *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &request{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := node.Call(req.Method, req.Params)
+	resp := &response{ID: req.ID}
+	if err != nil {
+		resp.Error = err
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		resp.Result = result
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+func param(params []json.RawMessage, index int, value interface{}) bool {
+	if index >= len(params) {
+		return false
+	}
+
+	return json.Unmarshal(params[index], value) == nil
+}
+
+// verbosity accepts both the numeric and the legacy boolean verbose flags
+func verbosity(params []json.RawMessage, index int, fallback int) int {
+	var level int
+	if param(params, index, &level) {
+		return level
+	}
+
+	var verbose bool
+	if param(params, index, &verbose) {
+		if verbose {
+			return 1
+		}
+		return 0
+	}
+
+	return fallback
+}
+
+// Call executes a single RPC method against the scripted chain
+func (node *Node) Call(method string, params []json.RawMessage) (interface{}, *btcjson.RPCError) {
+	switch method {
+	case "getinfo":
+		return map[string]interface{}{
+			"version":         NodeVersion,
+			"protocolversion": 90026,
+			"blocks":          node.Height(),
+		}, nil
+
+	case "getblockchaininfo":
+		return node.blockChainInfo(), nil
+
+	case "getblockcount":
+		return node.Height(), nil
+
+	case "getbestblockhash":
+		return node.blockHash(node.Height())
+
+	case "getblockhash":
+		var height int64
+		if !param(params, 0, &height) {
+			return nil, errInvalidParams
+		}
+		return node.blockHash(height)
+
+	case "getblock":
+		var hash string
+		if !param(params, 0, &hash) {
+			return nil, errInvalidParams
+		}
+		return node.getBlock(hash, verbosity(params, 1, 1))
+
+	case "getrawtransaction":
+		var txid string
+		if !param(params, 0, &txid) {
+			return nil, errInvalidParams
+		}
+		tx, err := node.getRawTransaction(txid)
+		if err != nil {
+			return nil, err
+		}
+		if verbosity(params, 1, 0) == 0 {
+			return tx.Hex, nil
+		}
+		return tx, nil
+
+	case "sendrawtransaction":
+		var rawHex string
+		if !param(params, 0, &rawHex) {
+			return nil, errInvalidParams
+		}
+		raw, err := hex.DecodeString(rawHex)
+		if err != nil {
+			return nil, errDecodeFailed
+		}
+		return node.sendRawTransaction(raw)
+
+	case "getaddressbalance":
+		var query struct {
+			Addresses []string `json:"addresses"`
+		}
+		if !param(params, 0, &query) || len(query.Addresses) != 1 {
+			return nil, errInvalidParams
+		}
+		return node.addressBalance(query.Addresses[0]), nil
+
+	case "elysium_listblocktransactions":
+		var height int64
+		if !param(params, 0, &height) {
+			return nil, errInvalidParams
+		}
+		return node.elysiumBlockTransactions(height)
+
+	case "elysium_gettransaction":
+		var txid string
+		if !param(params, 0, &txid) {
+			return nil, errInvalidParams
+		}
+		return node.elysiumTransaction(txid)
+
+	case "elysium_getsto":
+		var txid string
+		if !param(params, 0, &txid) {
+			return nil, errInvalidParams
+		}
+		return node.elysiumSTO(txid)
+
+	case "elysium_getproperty":
+		var propertyID int64
+		if !param(params, 0, &propertyID) {
+			return nil, errInvalidParams
+		}
+		return node.elysiumProperty(propertyID)
+
+	case "elysium_getallbalancesforaddress":
+		var address string
+		if !param(params, 0, &address) {
+			return nil, errInvalidParams
+		}
+		return node.elysiumBalances(address)
+
+	case "protx":
+		var command, kind string
+		var detailed bool
+		var height int64
+		if !param(params, 0, &command) || command != "list" ||
+			!param(params, 1, &kind) || kind != "registered" ||
+			!param(params, 2, &detailed) || !detailed ||
+			!param(params, 3, &height) {
+			return nil, errInvalidParams
+		}
+		return node.znodeList(height), nil
+
+	case "getrawmempool":
+		return node.mempoolHashes(), nil
+
+	default:
+		return nil, errMethodNotFound
+	}
+}
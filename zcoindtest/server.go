@@ -0,0 +1,55 @@
+package zcoindtest
+
+import (
+	"net/http/httptest"
+	"strings"
+
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/configuration"
+)
+
+// Server serves a stand-in node over HTTP for the lifetime of a test
+type Server struct {
+	*Node
+	HTTP *httptest.Server
+}
+
+// NewServer starts serving the given node on a local port
+func NewServer(node *Node) *Server {
+	return &Server{
+		Node: node,
+		HTTP: httptest.NewServer(node),
+	}
+}
+
+// Endpoint returns the host:port the node is listening on
+func (server *Server) Endpoint() string {
+	return strings.TrimPrefix(server.HTTP.URL, "http://")
+}
+
+// Config returns an application configuration pointing at the stand-in node
+func (server *Server) Config() *configuration.Config {
+	return &configuration.Config{
+		NetworkIdentifier: configuration.NetworkIdentifier{
+			Blockchain: "Zcoin",
+			Network:    "regtest",
+		},
+		Currency: configuration.Currency{
+			Symbol:   "XZC",
+			Decimals: 8,
+		},
+		Node: configuration.Node{
+			Endpoint: server.Endpoint(),
+			Username: "test",
+			Password: "test",
+		},
+		Version: configuration.Version{
+			RosettaVersion: "1.3.1",
+			ZcoinVersion:   "0.14.0.3",
+		},
+	}
+}
+
+// Close stops serving the node
+func (server *Server) Close() {
+	server.HTTP.Close()
+}
@@ -0,0 +1,69 @@
+package zcoindtest
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+
+	"github.com/go-zeromq/zmq4"
+)
+
+// Publisher is a stand-in for the zcoind ZMQ publisher, it publishes the
+// hashblock and rawtx topics on a single endpoint
+type Publisher struct {
+	mu       sync.Mutex
+	socket   zmq4.Socket
+	sequence map[string]uint32
+}
+
+// NewPublisher listens on the given endpoint, e.g. tcp://127.0.0.1:28332 or
+// tcp://127.0.0.1:0 to pick a free port
+func NewPublisher(ctx context.Context, endpoint string) (*Publisher, error) {
+	socket := zmq4.NewPub(ctx)
+	if err := socket.Listen(endpoint); err != nil {
+		socket.Close()
+		return nil, err
+	}
+
+	return &Publisher{
+		socket:   socket,
+		sequence: make(map[string]uint32),
+	}, nil
+}
+
+func (publisher *Publisher) publish(topic string, body []byte) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	sequence := make([]byte, 4)
+	binary.LittleEndian.PutUint32(sequence, publisher.sequence[topic])
+	publisher.sequence[topic]++
+
+	return publisher.socket.Send(zmq4.NewMsgFrom([]byte(topic), body, sequence))
+}
+
+// PublishBlock publishes a hashblock notification
+func (publisher *Publisher) PublishBlock(hash string) error {
+	body, err := hex.DecodeString(hash)
+	if err != nil {
+		return err
+	}
+
+	return publisher.publish("hashblock", body)
+}
+
+// PublishTransaction publishes a rawtx notification
+func (publisher *Publisher) PublishTransaction(raw []byte) error {
+	return publisher.publish("rawtx", raw)
+}
+
+// Endpoint returns the endpoint subscribers dial
+func (publisher *Publisher) Endpoint() string {
+	return "tcp://" + publisher.socket.Addr().String()
+}
+
+// Close stops publishing
+func (publisher *Publisher) Close() error {
+	return publisher.socket.Close()
+}
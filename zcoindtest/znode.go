@@ -0,0 +1,62 @@
+package zcoindtest
+
+import (
+	"gopkg.in/ArcadiaMediaGroup/zcoin-rosetta-node.v0/client"
+)
+
+type znodeEntry struct {
+	collateralHash  string
+	collateralIndex uint32
+	height          int64
+	info            client.ZnodeInfo
+}
+
+// RegisterZnode adds a Znode locking output n of tx to the Znode list, it is
+// registered from the next mined block on
+func (node *Node) RegisterZnode(tx *client.TxRawResult, n uint32, info client.ZnodeInfo) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	node.znodes = append(node.znodes, &znodeEntry{
+		collateralHash:  tx.Txid,
+		collateralIndex: n,
+		height:          int64(len(node.chain)),
+		info:            info,
+	})
+}
+
+// znodeList mirrors protx list registered true for the given height
+func (node *Node) znodeList(height int64) []client.ProTxInfo {
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+
+	list := make([]client.ProTxInfo, 0, len(node.znodes))
+	for _, entry := range node.znodes {
+		if entry.height > height {
+			continue
+		}
+
+		banHeight := int64(-1)
+		if entry.info.Status == client.ZnodePoSeBanned {
+			banHeight = entry.height
+		}
+		list = append(list, client.ProTxInfo{
+			ProTxHash:         entry.info.ProTxHash,
+			CollateralHash:    entry.collateralHash,
+			CollateralIndex:   entry.collateralIndex,
+			CollateralAddress: entry.info.CollateralAddress,
+			State: client.ProTxState{
+				Service:          entry.info.Address,
+				RegisteredHeight: entry.height,
+				LastPaidHeight:   entry.info.LastPaidBlock,
+				PoSeBanHeight:    banHeight,
+				OwnerAddress:     entry.info.OwnerAddress,
+				VotingAddress:    entry.info.VotingAddress,
+				PayoutAddress:    entry.info.Payee,
+				PubKeyOperator:   entry.info.PubKeyOperator,
+			},
+		})
+	}
+
+	return list
+}